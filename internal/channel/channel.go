@@ -1,31 +1,64 @@
 package channel
 
 import (
+	"math/rand"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/Pallinder/go-randomdata"
 	"github.com/diamondburned/cchat"
+	"github.com/diamondburned/cchat-mock/internal/emoji"
+	"github.com/diamondburned/cchat-mock/internal/message"
+	"github.com/diamondburned/cchat-mock/internal/search"
 	"github.com/diamondburned/cchat-mock/internal/shared"
 	"github.com/diamondburned/cchat-mock/segments"
 	"github.com/diamondburned/cchat/text"
 	"github.com/diamondburned/cchat/utils/empty"
 )
 
+// Parent is the server that a channel belongs to.
+type Parent interface {
+	// Emojis returns the custom emojis of the server.
+	Emojis() *emoji.Set
+	// Roster returns the members of the server.
+	Roster() *message.Roster
+	// Channels returns all channels in the server.
+	Channels() []*Channel
+}
+
 type Channel struct {
 	empty.Server
-	id   uint32
-	name string
-	user Username
+	state  *shared.State
+	parent Parent
+	id     uint32
+	name   string
+	user   *Nickname
+
+	// moderator is true if the current user can moderate this channel.
+	moderator bool
+	// code is true if this channel is designated for code samples.
+	code bool
+
+	// mutex guards the name and topic, which can be changed.
+	mutex sync.Mutex
+	topic string
+
+	// commandTimes is when each slash command was last run, used for ranking
+	// completions.
+	commandMutex sync.Mutex
+	commandTimes map[string]time.Time
 
 	messenger *Messenger
 }
 
 var _ cchat.Server = (*Channel)(nil)
 
-func NewChannels(state *shared.State, n int) []*Channel {
+func NewChannels(state *shared.State, parent Parent, n int) []*Channel {
 	var channels = make([]*Channel, n)
 	for i := range channels {
-		channels[i] = NewChannel(state)
+		channels[i] = NewChannel(state, parent)
 	}
 	return channels
 }
@@ -39,17 +72,24 @@ func AsCChatServers(channels []*Channel) []cchat.Server {
 }
 
 // NewChannel creates a new random channel.
-func NewChannel(state *shared.State) *Channel {
+func NewChannel(state *shared.State, parent Parent) *Channel {
+	var user segments.Builder
+	user.Colorful(state.Username, message.ReadableRGB(0xE88AF8)) // hot pink-ish colored
+
 	ch := &Channel{
-		id:   state.NextID(),
-		name: "#" + randomdata.Noun(),
-		user: Username{
-			Content: state.Username,
-			Segments: []text.Segment{
-				// hot pink-ish colored
-				segments.NewColoredSegment(state.Username, 0xE88AF8),
-			},
-		},
+		commandTimes: map[string]time.Time{},
+
+		state:  state,
+		parent: parent,
+		id:     state.NextID(),
+		name:   "#" + randomdata.Noun(),
+		user:   NewNickname(Username(user.Rich())),
+		// Moderate about a quarter of all channels.
+		moderator: rand.Intn(4) == 0,
+	}
+
+	if message.StressUnicode && rand.Intn(3) == 0 {
+		ch.name = "#" + message.StressName()
 	}
 
 	ch.messenger = NewMessenger(ch)
@@ -57,12 +97,56 @@ func NewChannel(state *shared.State) *Channel {
 	return ch
 }
 
+// NewCodeChannel creates a new random channel that is designated for code
+// samples.
+func NewCodeChannel(state *shared.State, parent Parent) *Channel {
+	ch := NewChannel(state, parent)
+	ch.name = "#code-" + randomdata.Noun()
+	ch.code = true
+	return ch
+}
+
 func (ch *Channel) ID() string {
 	return strconv.Itoa(int(ch.id))
 }
 
+// Name returns the channel name with a dimmed hash prefix.
 func (ch *Channel) Name() text.Rich {
-	return text.Plain(ch.name)
+	var name segments.Builder
+	name.Attributed("#", text.AttributeDimmed)
+	name.Write(strings.TrimPrefix(ch.rawName(), "#"))
+
+	return name.Rich()
+}
+
+// rawName returns the channel name with the hash prefix.
+func (ch *Channel) rawName() string {
+	ch.mutex.Lock()
+	defer ch.mutex.Unlock()
+
+	return ch.name
+}
+
+// SetName renames the channel. The hash prefix is optional.
+func (ch *Channel) SetName(name string) {
+	ch.mutex.Lock()
+	ch.name = "#" + strings.TrimPrefix(name, "#")
+	ch.mutex.Unlock()
+}
+
+// Search returns up to max messages in the backlog that match the given query,
+// newest first. The query's channel filter is ignored.
+func (ch *Channel) Search(q search.Query, max int) []message.Message {
+	var messages = ch.messenger.Messages()
+	var results []message.Message
+
+	for i := len(messages) - 1; i >= 0 && len(results) < max; i-- {
+		if q.Match(messages[i]) {
+			results = append(results, messages[i])
+		}
+	}
+
+	return results
 }
 
 func (ch *Channel) AsNicknamer() cchat.Nicknamer {
@@ -72,3 +156,47 @@ func (ch *Channel) AsNicknamer() cchat.Nicknamer {
 func (ch *Channel) AsMessenger() cchat.Messenger {
 	return ch.messenger
 }
+
+// Messenger returns the channel's messenger.
+func (ch *Channel) Messenger() *Messenger {
+	return ch.messenger
+}
+
+// self returns the current user as a message author.
+func (ch *Channel) self() message.Author {
+	return message.NewAuthor(ch.user.Username().Rich())
+}
+
+// Topic returns the channel topic, which is empty if none is set.
+func (ch *Channel) Topic() string {
+	ch.mutex.Lock()
+	defer ch.mutex.Unlock()
+
+	return ch.topic
+}
+
+// SetTopic sets the channel topic.
+func (ch *Channel) SetTopic(topic string) {
+	ch.mutex.Lock()
+	ch.topic = topic
+	ch.mutex.Unlock()
+}
+
+// ranCommand records that the command was just run.
+func (ch *Channel) ranCommand(name string) {
+	ch.commandMutex.Lock()
+	ch.commandTimes[name] = time.Now()
+	ch.commandMutex.Unlock()
+}
+
+// commandTime returns when the command was last run.
+func (ch *Channel) commandTime(name string) time.Time {
+	ch.commandMutex.Lock()
+	defer ch.commandMutex.Unlock()
+
+	return ch.commandTimes[name]
+}
+
+func (ch *Channel) AsCommander() cchat.Commander {
+	return &Commander{ch}
+}
@@ -0,0 +1,107 @@
+package channel
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/cchat"
+	"github.com/diamondburned/cchat-mock/internal/internet"
+	"github.com/diamondburned/cchat-mock/internal/search"
+	"github.com/diamondburned/cchat/text"
+	"github.com/pkg/errors"
+)
+
+// Commander is the command prompt of a single channel.
+type Commander struct {
+	ch *Channel
+}
+
+var _ cchat.Commander = (*Commander)(nil)
+
+// Commands is the list of commands that the channel Commander supports.
+var Commands = []string{"ls", "pins", "search"}
+
+func (c *Commander) Run(cmds []string) ([]byte, error) {
+	if err := c.ch.state.Check(); err != nil {
+		return nil, err
+	}
+
+	switch cmd := arg(cmds, 0); cmd {
+	case "ls":
+		return []byte("Commands: " + strings.Join(Commands, ", ")), nil
+
+	case "pins":
+		if err := internet.SimulateAustralian(); err != nil {
+			return nil, errors.Wrap(err, "Failed to fetch pins")
+		}
+
+		var pins = c.ch.messenger.Pins()
+		if len(pins) == 0 {
+			return []byte("No pinned messages."), nil
+		}
+
+		var buf bytes.Buffer
+		for _, pin := range pins {
+			fmt.Fprintf(
+				&buf, "%s [%s] %s: %s\n",
+				pin.ID(), pin.Time().Format(time.Kitchen), pin.AuthorName(), pin.Content(),
+			)
+		}
+
+		return buf.Bytes(), nil
+
+	case "search":
+		q, err := search.Parse(cmds[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		if err := internet.SimulateAustralian(); err != nil {
+			return nil, errors.Wrap(err, "Failed to search")
+		}
+
+		var results = c.ch.Search(q, search.MaxResults)
+		if len(results) == 0 {
+			return []byte("No results."), nil
+		}
+
+		var buf bytes.Buffer
+		for _, msg := range results {
+			search.WriteResult(&buf, c.ch.rawName(), msg)
+		}
+
+		return buf.Bytes(), nil
+
+	default:
+		return nil, fmt.Errorf("Unknown command: %q", cmd)
+	}
+}
+
+func (c *Commander) AsCompleter() cchat.Completer { return c }
+
+func (c *Commander) Complete(words []string, i int64) []cchat.CompletionEntry {
+	if i > 0 {
+		return nil
+	}
+
+	var entries []cchat.CompletionEntry
+	for _, cmd := range Commands {
+		if strings.HasPrefix(cmd, words[i]) {
+			entries = append(entries, cchat.CompletionEntry{
+				Raw:  cmd,
+				Text: text.Plain(cmd),
+			})
+		}
+	}
+
+	return entries
+}
+
+func arg(sl []string, i int) string {
+	if i >= len(sl) {
+		return ""
+	}
+	return sl[i]
+}
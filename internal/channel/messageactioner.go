@@ -1,7 +1,8 @@
 package channel
 
 import (
-	"strconv"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/diamondburned/cchat"
@@ -27,25 +28,63 @@ const (
 	NoopAction          = "No-op"
 	BestCharacterAction = "Who's the best character?"
 	TriggerTypingAction = "Trigger Typing"
+	EditHistoryAction   = "Show Edit History"
+	PinAction           = "Pin"
+	UnpinAction         = "Unpin"
 )
 
+// Actions returns the actions available for the given message. Deleting is
+// only allowed on the user's own messages or if the user is a moderator, and
+// editing is only allowed on the user's own messages.
 func (msga MessageActioner) Actions(id string) []string {
-	return []string{
-		DeleteAction,
-		NoopAction,
-		BestCharacterAction,
-		TriggerTypingAction,
+	m, ok := msga.msgr.message(id)
+	if !ok {
+		return nil
 	}
+
+	var actions []string
+
+	if msga.msgr.canDelete(m) {
+		actions = append(actions, DeleteAction)
+	}
+
+	actions = append(actions, NoopAction)
+
+	if msga.msgr.canEdit(m) {
+		actions = append(actions, BestCharacterAction)
+	}
+
+	actions = append(actions, TriggerTypingAction)
+
+	if msga.msgr.IsPinned(id) {
+		actions = append(actions, UnpinAction)
+	} else {
+		actions = append(actions, PinAction)
+	}
+
+	if m.IsEdited() {
+		actions = append(actions, EditHistoryAction)
+	}
+
+	return actions
 }
 
 // Do will be blocked by IO. As goes for every other method that takes a
 // container: the frontend should call this in a goroutine.
 func (msga MessageActioner) Do(action, messageID string) error {
+	if err := msga.msgr.channel.state.Check(); err != nil {
+		return err
+	}
+
 	switch action {
 	case DeleteAction, TriggerTypingAction:
-		i, err := strconv.Atoi(messageID)
-		if err != nil {
-			return errors.Wrap(err, "Invalid ID")
+		m, ok := msga.msgr.message(messageID)
+		if !ok {
+			return errors.New("Message not found.")
+		}
+
+		if action == DeleteAction && !msga.msgr.canDelete(m) {
+			return errors.Wrap(ErrPermissionDenied, "Cannot delete others' messages")
 		}
 
 		// Simulate IO.
@@ -55,9 +94,9 @@ func (msga MessageActioner) Do(action, messageID string) error {
 
 		switch action {
 		case DeleteAction:
-			msga.msgr.del <- message.NewHeader(uint32(i), time.Now())
+			return msga.msgr.deleteAsync(message.NewHeader(m.RealID(), time.Now()))
 		case TriggerTypingAction:
-			msga.msgr.typ.TriggerTyping(msga.msgr.messages[uint32(i)].RealAuthor())
+			msga.msgr.typ.TriggerTyping(m.RealAuthor())
 		}
 
 	case NoopAction:
@@ -66,9 +105,56 @@ func (msga MessageActioner) Do(action, messageID string) error {
 	case BestCharacterAction:
 		return msga.msgr.Edit(messageID, "Astolfo.")
 
+	case PinAction, UnpinAction:
+		// Simulate IO.
+		if err := internet.SimulateAustralian(); err != nil {
+			return err
+		}
+
+		var verb string
+
+		switch action {
+		case PinAction:
+			if err := msga.msgr.Pin(messageID); err != nil {
+				return err
+			}
+			verb = "pinned"
+		case UnpinAction:
+			if err := msga.msgr.Unpin(messageID); err != nil {
+				return err
+			}
+			verb = "unpinned"
+		}
+
+		return msga.msgr.notify(fmt.Sprintf("%s %s a message.", msga.msgr.channel.user, verb))
+
+	case EditHistoryAction:
+		revisions, err := msga.msgr.History(messageID)
+		if err != nil {
+			return err
+		}
+
+		return msga.msgr.notify(formatHistory(messageID, revisions))
+
 	default:
 		return errors.New("Unknown action.")
 	}
 
 	return nil
 }
+
+// formatHistory formats the revisions into a human-readable list.
+func formatHistory(messageID string, revisions []message.Revision) string {
+	if len(revisions) == 0 {
+		return fmt.Sprintf("Message %s has never been edited.", messageID)
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Edit history of message %s:", messageID)
+
+	for _, rev := range revisions {
+		fmt.Fprintf(&builder, "\n[%s] %s", rev.Time.Format(time.Kitchen), rev.Content)
+	}
+
+	return builder.String()
+}
@@ -4,16 +4,39 @@ import (
 	"strings"
 
 	"github.com/diamondburned/cchat"
+	"github.com/diamondburned/cchat-mock/internal/complete"
+	"github.com/diamondburned/cchat-mock/internal/emoji"
 	"github.com/diamondburned/cchat-mock/internal/message"
+	"github.com/diamondburned/cchat/text"
 )
 
+// maxCompletions is the maximum number of completion entries to return.
+const maxCompletions = 25
+
 type MessageCompleter struct {
 	msgr *Messenger
 }
 
+// Complete completes @members from the server roster, #channels from the
+// server, /commands from the slash commands at the start of the message and
+// :emojis:. Other words are completed with member names by prefix.
 func (msgc MessageCompleter) Complete(words []string, i int64) []cchat.CompletionEntry {
+	var word = words[i]
+
 	switch {
-	case strings.HasPrefix("complete", words[i]):
+	case strings.HasPrefix(word, "@"):
+		return complete.Rank(word[1:], msgc.members("@"), maxCompletions)
+
+	case strings.HasPrefix(word, "#"):
+		return complete.Rank(word[1:], msgc.channels(), maxCompletions)
+
+	case strings.HasPrefix(word, "/") && i == 0:
+		return complete.Rank(word[1:], msgc.commands(), maxCompletions)
+
+	case strings.HasPrefix(word, ":"):
+		return complete.Rank(strings.Trim(word, ":"), msgc.emojis(), maxCompletions)
+
+	case strings.HasPrefix("complete", word):
 		return makeCompletion(
 			"complete",
 			"complete me",
@@ -39,34 +62,104 @@ func (msgc MessageCompleter) Complete(words []string, i int64) []cchat.Completio
 		)
 
 	default:
-		var found = map[string]struct{}{}
+		// Only complete member names by prefix, since fuzzy matching every
+		// word would be too noisy.
+		var members []complete.Candidate
+		for _, member := range msgc.members("") {
+			if strings.HasPrefix(strings.ToLower(member.Name), strings.ToLower(word)) {
+				members = append(members, member)
+			}
+		}
 
-		msgc.msgr.messageMutex.Lock()
-		defer msgc.msgr.messageMutex.Unlock()
+		return complete.Rank(word, members, maxCompletions)
+	}
+}
 
-		var entries []cchat.CompletionEntry
+// members returns the server roster as candidates, with Raw prefixed.
+func (msgc MessageCompleter) members(prefix string) []complete.Candidate {
+	var members = msgc.msgr.channel.parent.Roster().Members()
+	var candidates = make([]complete.Candidate, len(members))
+
+	for i, member := range members {
+		candidates[i] = complete.Candidate{
+			Name:    member.ID(),
+			Raw:     prefix + member.ID(),
+			Text:    member.Name(),
+			IconURL: member.Avatar(),
+			Time:    member.LastSpoke,
+		}
+	}
 
-		// Look for members.
-		for _, id := range msgc.msgr.messageids {
-			if msg := msgc.msgr.messages[id]; strings.HasPrefix(msg.AuthorName(), words[i]) {
-				if _, ok := found[msg.AuthorName()]; ok {
-					continue
-				}
+	return candidates
+}
 
-				found[msg.AuthorName()] = struct{}{}
+// channels returns the server's channels as candidates, ranked by activity.
+func (msgc MessageCompleter) channels() []complete.Candidate {
+	var channels = msgc.msgr.channel.parent.Channels()
+	var candidates = make([]complete.Candidate, len(channels))
 
-				entries = append(entries, cchat.CompletionEntry{
-					Raw:     msg.AuthorName(),
-					Text:    msg.Author().Name(),
-					IconURL: msg.Author().Avatar(),
-				})
-			}
+	for i, ch := range channels {
+		var name = ch.rawName()
+
+		candidates[i] = complete.Candidate{
+			Name: strings.TrimPrefix(name, "#"),
+			Raw:  name,
+			Text: ch.Name(),
+			Time: ch.messenger.LastActivity(),
+		}
+	}
+
+	return candidates
+}
+
+// commands returns the slash commands as candidates, ranked by when they were
+// last run.
+func (msgc MessageCompleter) commands() []complete.Candidate {
+	var candidates = make([]complete.Candidate, len(SlashCommands))
+
+	for i, cmd := range SlashCommands {
+		candidates[i] = complete.Candidate{
+			Name:      cmd.Name,
+			Raw:       "/" + cmd.Name,
+			Text:      text.Plain(strings.TrimSpace("/" + cmd.Name + " " + cmd.Usage)),
+			Secondary: text.Plain(cmd.Help),
+			Time:      msgc.msgr.channel.commandTime(cmd.Name),
 		}
+	}
+
+	return candidates
+}
+
+// emojis returns the Unicode emojis and the server's custom emojis as
+// candidates.
+func (msgc MessageCompleter) emojis() []complete.Candidate {
+	var set = msgc.msgr.channel.parent.Emojis()
+	var candidates = make([]complete.Candidate, 0, len(emoji.Unicode)+len(set.Names()))
+
+	for name, unicode := range emoji.Unicode {
+		var code = ":" + name + ":"
+		candidates = append(candidates, complete.Candidate{
+			Name: name,
+			Raw:  code,
+			Text: text.Plain(unicode + " " + code),
+		})
+	}
 
-		return entries
+	for _, name := range set.Names() {
+		var code = ":" + name + ":"
+		var custom, _ = set.Custom(name)
+
+		candidates = append(candidates, complete.Candidate{
+			Name:      name,
+			Raw:       code,
+			Text:      text.Plain(code),
+			Secondary: text.Plain("Custom emoji"),
+			IconURL:   custom.URL,
+			Image:     true,
+		})
 	}
 
-	return nil
+	return candidates
 }
 
 func makeCompletion(word ...string) []cchat.CompletionEntry {
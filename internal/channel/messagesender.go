@@ -1,6 +1,8 @@
 package channel
 
 import (
+	"context"
+	"strings"
 	"time"
 
 	"github.com/diamondburned/cchat"
@@ -26,18 +28,42 @@ func NewMessageSender(msgr *Messenger) MessageSender {
 func (msgs MessageSender) CanAttach() bool { return false }
 
 func (msgs MessageSender) Send(msg cchat.SendableMessage) error {
+	if err := msgs.msgr.channel.state.Check(); err != nil {
+		return err
+	}
+
 	if err := internet.SimulateAustralian(); err != nil {
 		return errors.Wrap(err, "Failed to send message")
 	}
 
-	go func() {
+	// Messages starting with a slash are commands, unless the slash is
+	// escaped with another slash.
+	if content := msg.Content(); strings.HasPrefix(content, "//") {
+		msg = sentMessage{msg, content[1:], false}
+	} else if strings.HasPrefix(content, "/") {
+		return msgs.runSlash(msg)
+	}
+
+	msgs.queue(msg)
+	return nil
+}
+
+// queue sends the message into the channel after a delay.
+func (msgs MessageSender) queue(msg cchat.SendableMessage) {
+	msgs.msgr.async(func(ctx context.Context) {
 		// Make no guarantee that a message may arrive immediately when the
 		// function exits.
-		<-time.After(time.Second)
-		msgs.ch <- msg
-	}()
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			return
+		}
 
-	return nil
+		select {
+		case msgs.ch <- msg:
+		case <-ctx.Done():
+		}
+	})
 }
 
 func (msgs MessageSender) AsCompleter() cchat.Completer {
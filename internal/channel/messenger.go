@@ -15,26 +15,40 @@ import (
 	"github.com/pkg/errors"
 )
 
+// ErrPermissionDenied is returned when the current user tries to act on a
+// message that they're not allowed to.
+var ErrPermissionDenied = errors.New("Permission denied")
+
 // FetchBacklog is the number of messages to fake-fetmsgr.
 const FetchBacklog = 35
 const maxBacklog = FetchBacklog * 2
 
+// DefaultRate is the default interval between generated messages.
+const DefaultRate = 4 * time.Second
+
 // max number to add to before the next author, with rand.Intn(limit) + incr.
 const sameAuthorLimit = 6
 
 type Messenger struct {
+	rate int64 // atomic time.Duration, first for alignment
+
 	empty.Messenger
 	channel *Channel
 
 	send MessageSender
+	post chan message.Message
 	edit chan message.Message // id
 	del  chan message.Header
-	typ  typing.Subscriber
+	typ  *typing.Broadcaster
 
 	messageMutex sync.Mutex
 	messages     map[uint32]message.Message
 	messageids   []uint32 // indices
+	pins         []uint32 // pinned message IDs, oldest first
+	listeners    map[chan message.Message]struct{}
 
+	// joins is the number of active JoinServer loops.
+	joins int32
 	// used for unique ID generation of messages
 	incrID uint32
 	// used for generating the same author multiple times before shuffling, goes
@@ -45,22 +59,30 @@ type Messenger struct {
 var _ cchat.Messenger = (*Messenger)(nil)
 
 func NewMessenger(ch *Channel) *Messenger {
-	msgr := Messenger{}
+	msgr := Messenger{rate: int64(DefaultRate)}
 	msgr.channel = ch
 	// Initialize.
 	msgr.messages = make(map[uint32]message.Message, FetchBacklog)
 	msgr.messageids = make([]uint32, 0, FetchBacklog)
+	msgr.listeners = map[chan message.Message]struct{}{}
 
-	// Allocate 3 channels that we won't clean up, because we're lazy.
+	// Goroutines that use these channels stop when the session is closed.
 	msgr.send = NewMessageSender(&msgr)
+	msgr.post = make(chan message.Message)
 	msgr.edit = make(chan message.Message)
 	msgr.del = make(chan message.Header)
-	msgr.typ = typing.NewSubscriber(message.NewAuthor(msgr.channel.user.Rich()))
+	msgr.typ = typing.NewBroadcaster(ch.state, msgr.channel.self())
 
 	return &msgr
 }
 
 func (msgr *Messenger) JoinServer(ctx context.Context, ct cchat.MessagesContainer) (func(), error) {
+	if err := msgr.channel.state.Check(); err != nil {
+		return nil, err
+	}
+
+	ct = wrapContainer(ct)
+
 	// Is this a fresh channel? If yes, generate messages with some IO latency.
 	if len(msgr.messageids) == 0 || msgr.messages == nil {
 		// Simulate IO and error.
@@ -80,40 +102,102 @@ func (msgr *Messenger) JoinServer(ctx context.Context, ct cchat.MessagesContaine
 	}
 
 	// Initialize context for cancellation. The context passed in is used only
-	// for initialization, so we'll use our own context for the loop.
-	ctx, stop := context.WithCancel(context.Background())
+	// for initialization, so we'll use our own context for the loop, which
+	// also stops when the session is closed.
+	ctx, stop := context.WithCancel(msgr.channel.state.Context())
+
+	// Count the join before the loop starts, so that Joins is accurate as soon
+	// as JoinServer returns.
+	atomic.AddInt32(&msgr.joins, 1)
+
+	err := msgr.async(func(context.Context) {
+		defer atomic.AddInt32(&msgr.joins, -1)
 
-	go func() {
-		ticker := time.NewTicker(4 * time.Second)
-		defer ticker.Stop()
+		rate := msgr.Rate()
+
+		ticker := time.NewTicker(rate)
+		defer func() { ticker.Stop() }()
 
 		editTick := time.NewTicker(10 * time.Second)
 		defer editTick.Stop()
 
+		pinTick := time.NewTicker(45 * time.Second)
+		defer pinTick.Stop()
+
 		// deleteTick := time.NewTicker(15 * time.Second)
 		// defer deleteTick.Stop()
 
+		// arrive receives generated messages once their authors are done
+		// typing them.
+		arrive := make(chan message.Message)
+		// typing counts the pending messages of each author by ID, so that
+		// the indicator only stops once the last of them has arrived.
+		typing := map[string]int{}
+
 		for {
 			select {
 			case msg := <-msgr.send.ch:
-				msgr.addMessage(message.Echo(
-					msg,
-					msgr.nextID(),
-					message.NewAuthor(msgr.channel.user.Rich()),
-				), ct)
+				msgr.addMessage(msgr.echo(msg), ct)
+
+			case msg := <-msgr.post:
+				ct.CreateMessage(msg)
 
 			case msg := <-msgr.edit:
 				ct.UpdateMessage(msg)
 
 			case msh := <-msgr.del:
-				msgr.deleteMessage(msh, ct)
+				ct.DeleteMessage(msh)
 
 			case <-ticker.C:
-				msgr.addMessage(msgr.randomMsg(), ct)
+				// Pick up rate changes.
+				if newRate := msgr.Rate(); newRate != rate {
+					rate = newRate
+					ticker.Stop()
+					ticker = time.NewTicker(rate)
+				}
+
+				// Have the next author type for a bit before the message
+				// arrives.
+				var author = msgr.nextAuthor()
+				if typing[author.ID()]++; typing[author.ID()] == 1 {
+					msgr.typ.StartTyping(author)
+				}
+
+				msgr.async(func(context.Context) {
+					select {
+					case <-time.After(randomTypingDelay()):
+					case <-ctx.Done():
+						return
+					}
+
+					select {
+					case arrive <- msgr.newRandomMsg(author):
+					case <-ctx.Done():
+					}
+				})
+
+			case msg := <-arrive:
+				var author = msg.RealAuthor()
+				if typing[author.ID()]--; typing[author.ID()] == 0 {
+					delete(typing, author.ID())
+					msgr.typ.StopTyping(author)
+				}
+				msgr.addMessage(msg, ct)
 
 			case <-editTick.C:
-				var old = msgr.randomOldMsg()
-				msgr.updateMessage(message.NewRandomFromMessage(old), ct)
+				if old, ok := msgr.randomOldMsg(); ok {
+					msgr.updateMessage(message.NewRandomFromMessage(old), ct)
+				}
+
+			case <-pinTick.C:
+				// Have someone else pin a random recent message.
+				old, ok := msgr.randomOldMsg()
+				if ok && msgr.Pin(old.ID()) == nil {
+					msgr.addMessage(message.NewSystem(
+						msgr.nextID(),
+						message.RandomAuthor().Name().String()+" pinned a message.",
+					), ct)
+				}
 
 			// case <-deleteTick.C:
 			// 	var old = msgr.randomOldMsg()
@@ -123,7 +207,12 @@ func (msgr *Messenger) JoinServer(ctx context.Context, ct cchat.MessagesContaine
 				return
 			}
 		}
-	}()
+	})
+	if err != nil {
+		atomic.AddInt32(&msgr.joins, -1)
+		stop()
+		return nil, err
+	}
 
 	return stop, nil
 }
@@ -132,25 +221,165 @@ func (msgr *Messenger) nextID() (id uint32) {
 	return atomic.AddUint32(&msgr.incrID, 1)
 }
 
-func (msgr *Messenger) AsEditor() cchat.Editor { return msgr }
+// LastID returns the last generated message ID.
+func (msgr *Messenger) LastID() uint32 {
+	return atomic.LoadUint32(&msgr.incrID)
+}
 
-// IsEditable returns true if the message belongs to the author.
-func (msgr *Messenger) IsEditable(id string) bool {
-	i, err := message.ParseID(id)
-	if err != nil {
-		return false
+// Joins returns the number of active JoinServer subscriptions.
+func (msgr *Messenger) Joins() int {
+	return int(atomic.LoadInt32(&msgr.joins))
+}
+
+// Rate returns the interval between generated messages.
+func (msgr *Messenger) Rate() time.Duration {
+	return time.Duration(atomic.LoadInt64(&msgr.rate))
+}
+
+// SetRate sets the interval between generated messages. It takes effect after
+// the next generated message.
+func (msgr *Messenger) SetRate(rate time.Duration) {
+	atomic.StoreInt64(&msgr.rate, int64(rate))
+}
+
+// Inject posts a new message from the given author. The message ID is
+// returned.
+func (msgr *Messenger) Inject(author message.Author, content string) (string, error) {
+	var msg = message.New(msgr.nextID(), author, content)
+	if err := msgr.postAsync(msg); err != nil {
+		return "", err
 	}
 
+	return msg.ID(), nil
+}
+
+// Delete deletes the message with the given ID regardless of permissions.
+func (msgr *Messenger) Delete(id string) error {
+	m, ok := msgr.message(id)
+	if !ok {
+		return errors.New("Message not found.")
+	}
+
+	return msgr.deleteAsync(m.Header)
+}
+
+// Subscribe returns a channel that receives new messages as they're added, and
+// a callback to unsubscribe. Messages are dropped if the buffer is full. New
+// messages are only generated while the channel is joined.
+func (msgr *Messenger) Subscribe(buffer int) (<-chan message.Message, func()) {
+	var listener = make(chan message.Message, buffer)
+
 	msgr.messageMutex.Lock()
-	defer msgr.messageMutex.Unlock()
+	msgr.listeners[listener] = struct{}{}
+	msgr.messageMutex.Unlock()
 
-	m, ok := msgr.messages[i]
-	if ok {
-		// Editable if same author.
-		return m.Author().Name().String() == msgr.channel.user.String()
+	return listener, func() {
+		msgr.messageMutex.Lock()
+		delete(msgr.listeners, listener)
+		msgr.messageMutex.Unlock()
 	}
+}
 
-	return false
+// Broadcaster returns the typing broadcaster of the channel.
+func (msgr *Messenger) Broadcaster() *typing.Broadcaster {
+	return msgr.typ
+}
+
+// echo creates the message sent by the current user.
+func (msgr *Messenger) echo(msg cchat.SendableMessage) message.Message {
+	var echo = message.Echo(msg, msgr.nextID(), msgr.channel.self()).WithSelf()
+	if sent, ok := msg.(sentMessage); ok && sent.action {
+		echo = echo.WithAction()
+	}
+	return echo
+}
+
+// notify posts a system message without blocking.
+func (msgr *Messenger) notify(content string) error {
+	return msgr.postAsync(message.NewSystem(msgr.nextID(), content))
+}
+
+// async runs fn in a goroutine that is stopped and waited for when the session
+// is closed. It returns ErrDisconnected if the session is already closed.
+func (msgr *Messenger) async(fn func(ctx context.Context)) error {
+	return msgr.channel.state.Go(fn)
+}
+
+// postAsync adds the message into the backlog and posts it into the joined
+// channel without blocking. If the channel isn't joined, the message is only
+// added into the backlog.
+func (msgr *Messenger) postAsync(msg message.Message) error {
+	msg = msgr.storeMessage(msg)
+
+	if msgr.Joins() == 0 {
+		return nil
+	}
+
+	return msgr.async(func(ctx context.Context) {
+		select {
+		case msgr.post <- msg:
+		case <-ctx.Done():
+		}
+	})
+}
+
+// updateAsync sends the updated message into the joined channel without
+// blocking. The caller must have already updated the backlog.
+func (msgr *Messenger) updateAsync(msg message.Message) error {
+	if msgr.Joins() == 0 {
+		return nil
+	}
+
+	return msgr.async(func(ctx context.Context) {
+		select {
+		case msgr.edit <- msg:
+		case <-ctx.Done():
+		}
+	})
+}
+
+// deleteAsync removes the message from the backlog and deletes it in the
+// joined channel without blocking.
+func (msgr *Messenger) deleteAsync(msg message.Header) error {
+	if !msgr.removeMessage(msg.RealID()) {
+		return errors.New("Message not found.")
+	}
+
+	if msgr.Joins() == 0 {
+		return nil
+	}
+
+	return msgr.async(func(ctx context.Context) {
+		select {
+		case msgr.del <- msg:
+		case <-ctx.Done():
+		}
+	})
+}
+
+func (msgr *Messenger) AsEditor() cchat.Editor { return msgr }
+
+// IsEditable returns true if the message belongs to the author.
+func (msgr *Messenger) IsEditable(id string) bool {
+	m, ok := msgr.message(id)
+	return ok && msgr.canEdit(m)
+}
+
+// isOwn returns true if the message was sent by the current user.
+func (msgr *Messenger) isOwn(m message.Message) bool {
+	return m.IsSelf()
+}
+
+// canEdit returns true if the current user is allowed to edit the message.
+// Only the author can edit a message.
+func (msgr *Messenger) canEdit(m message.Message) bool {
+	return msgr.isOwn(m)
+}
+
+// canDelete returns true if the current user is allowed to delete the message.
+// Moderators can delete anyone's message.
+func (msgr *Messenger) canDelete(m message.Message) bool {
+	return msgr.isOwn(m) || msgr.channel.moderator
 }
 
 func (msgr *Messenger) RawContent(id string) (string, error) {
@@ -164,7 +393,7 @@ func (msgr *Messenger) RawContent(id string) (string, error) {
 
 	m, ok := msgr.messages[i]
 	if ok {
-		return m.Content().String(), nil
+		return m.RawContent(), nil
 	}
 
 	return "", errors.New("Message not found")
@@ -176,6 +405,10 @@ func (msgr *Messenger) Edit(id, content string) error {
 		return err
 	}
 
+	if err := msgr.channel.state.Check(); err != nil {
+		return err
+	}
+
 	if err := internet.SimulateAustralian(); err != nil {
 		return err
 	}
@@ -185,17 +418,77 @@ func (msgr *Messenger) Edit(id, content string) error {
 
 	m, ok := msgr.messages[i]
 	if ok {
-		m.SetContent(content)
+		if !msgr.canEdit(m) {
+			return errors.Wrap(ErrPermissionDenied, "Cannot edit others' messages")
+		}
+
+		m.Edit(content, time.Now())
 		msgr.messages[i] = m
-		msgr.edit <- m
 
-		return nil
+		return msgr.updateAsync(m)
 	}
 
 	return errors.New("Message not found.")
 }
 
+// message returns the message with the given ID, if any.
+func (msgr *Messenger) message(id string) (message.Message, bool) {
+	i, err := message.ParseID(id)
+	if err != nil {
+		return message.Message{}, false
+	}
+
+	msgr.messageMutex.Lock()
+	defer msgr.messageMutex.Unlock()
+
+	m, ok := msgr.messages[i]
+	return m, ok
+}
+
+// Messages returns a snapshot of all messages in the backlog, oldest first.
+func (msgr *Messenger) Messages() []message.Message {
+	msgr.messageMutex.Lock()
+	defer msgr.messageMutex.Unlock()
+
+	var messages = make([]message.Message, len(msgr.messageids))
+	for i, id := range msgr.messageids {
+		messages[i] = msgr.messages[id]
+	}
+
+	return messages
+}
+
+// LastActivity returns the time of the last message, or a zero time if there
+// are no messages.
+func (msgr *Messenger) LastActivity() time.Time {
+	msgr.messageMutex.Lock()
+	defer msgr.messageMutex.Unlock()
+
+	if len(msgr.messageids) == 0 {
+		return time.Time{}
+	}
+
+	return msgr.messages[msgr.messageids[len(msgr.messageids)-1]].Time()
+}
+
+// History returns the previous revisions of the message with the given ID.
+func (msgr *Messenger) History(id string) ([]message.Revision, error) {
+	m, ok := msgr.message(id)
+	if !ok {
+		return nil, errors.New("Message not found.")
+	}
+
+	return m.Revisions(), nil
+}
+
 func (msgr *Messenger) addMessage(msg message.Message, container cchat.MessagesContainer) {
+	container.CreateMessage(msgr.storeMessage(msg))
+}
+
+// storeMessage adds the message into the backlog and returns it as stored.
+func (msgr *Messenger) storeMessage(msg message.Message) message.Message {
+	msg = msg.WithEmojis(msgr.channel.parent.Emojis())
+
 	msgr.messageMutex.Lock()
 
 	// Clean up the backlog.
@@ -203,6 +496,7 @@ func (msgr *Messenger) addMessage(msg message.Message, container cchat.MessagesC
 		// Remove them from the map.
 		for _, id := range msgr.messageids[:clean] {
 			delete(msgr.messages, id)
+			msgr.unpin(id)
 		}
 
 		// Cut the message IDs away by shifting the slice.
@@ -212,9 +506,20 @@ func (msgr *Messenger) addMessage(msg message.Message, container cchat.MessagesC
 	msgr.messages[msg.RealID()] = msg
 	msgr.messageids = append(msgr.messageids, msg.RealID())
 
+	for listener := range msgr.listeners {
+		select {
+		case listener <- msg:
+		default:
+		}
+	}
+
 	msgr.messageMutex.Unlock()
 
-	container.CreateMessage(msg)
+	if !msg.RealAuthor().Equal(message.SystemAuthor) {
+		msgr.channel.parent.Roster().Spoke(msg.RealAuthor(), msg.Time())
+	}
+
+	return msg
 }
 
 func (msgr *Messenger) updateMessage(msg message.Message, container cchat.MessagesContainer) {
@@ -233,47 +538,67 @@ func (msgr *Messenger) updateMessage(msg message.Message, container cchat.Messag
 }
 
 func (msgr *Messenger) deleteMessage(msg message.Header, container cchat.MessagesContainer) {
+	if msgr.removeMessage(msg.RealID()) {
+		container.DeleteMessage(msg)
+	}
+}
+
+// removeMessage removes the message from the backlog. False is returned if the
+// message isn't in it.
+func (msgr *Messenger) removeMessage(msgID uint32) bool {
 	msgr.messageMutex.Lock()
+	defer msgr.messageMutex.Unlock()
 
 	// Delete from the map.
-	delete(msgr.messages, msg.RealID())
+	delete(msgr.messages, msgID)
+	msgr.unpin(msgID)
 
 	// Delete from the ordered slice.
-	var ok bool
 	for i, id := range msgr.messageids {
-		if id == msg.RealID() {
+		if id == msgID {
 			msgr.messageids = append(msgr.messageids[:i], msgr.messageids[i+1:]...)
-			ok = true
-			break
+			return true
 		}
 	}
 
-	msgr.messageMutex.Unlock()
-
-	if ok {
-		container.DeleteMessage(msg)
-	}
+	return false
 }
 
-// randomMsgID returns a random recent message ID.
-func (msgr *Messenger) randomOldMsg() message.Message {
+// randomOldMsg returns a random recent message. False is returned if the
+// backlog is empty, such as after it was cleared.
+func (msgr *Messenger) randomOldMsg() (message.Message, bool) {
 	msgr.messageMutex.Lock()
 	defer msgr.messageMutex.Unlock()
 
+	if len(msgr.messageids) == 0 {
+		return message.Message{}, false
+	}
+
 	// Pick a random index from last, clamped to 10 and len channel.
 	n := len(msgr.messageids) - 1 - rand.Intn(len(msgr.messageids))%10
-	return msgr.messages[msgr.messageids[n]]
+	return msgr.messages[msgr.messageids[n]], true
+}
+
+// randomTypingDelay returns how long a generated author types before their
+// message arrives, which is between 2 and 7 seconds.
+func randomTypingDelay() time.Duration {
+	return time.Duration(rand.Intn(5000)+2000) * time.Millisecond
+}
+
+// randomMsg returns a new random message from the next author.
+func (msgr *Messenger) randomMsg() message.Message {
+	return msgr.newRandomMsg(msgr.nextAuthor())
 }
 
-// randomMsg uses top of the state algorithms to return fair and balanced
-// messages suitable for rigorous testing.
-func (msgr *Messenger) randomMsg() (msg message.Message) {
+// nextAuthor uses top of the state algorithms to return fair and balanced
+// authors suitable for rigorous testing.
+func (msgr *Messenger) nextAuthor() message.Author {
 	msgr.messageMutex.Lock()
 	defer msgr.messageMutex.Unlock()
 
 	// If we don't have any messages, then skip.
 	if len(msgr.messages) == 0 {
-		return message.Random(msgr.nextID())
+		return message.RandomAuthor()
 	}
 
 	// Add a random number into incrAuthor and determine if that should be
@@ -283,17 +608,35 @@ func (msgr *Messenger) randomMsg() (msg message.Message) {
 	var lastID = msgr.messageids[len(msgr.messageids)-1]
 	var lastAu = msgr.messages[lastID].RealAuthor()
 
-	// If the last author is not the current user, then we can use it.
-	// Should we generate a new author for the new message? No if we're not over
-	// the limits.
-	if !lastAu.Equal(msg.RealAuthor()) && msgr.incrAuthor < sameAuthorLimit {
-		msg = message.RandomWithAuthor(msgr.nextID(), lastAu)
-	} else {
-		msg = message.Random(msgr.nextID())
-		msgr.incrAuthor = 0 // reset
+	// If the last author is not the current user and has a character to write
+	// messages with, then we can use it. Should we generate a new author for
+	// the new message? No if we're not over the limits.
+	if !msgr.isOwn(msgr.messages[lastID]) && lastAu.HasCharacter() &&
+		msgr.incrAuthor < sameAuthorLimit {
+		return lastAu
+	}
+
+	msgr.incrAuthor = 0 // reset
+	return message.RandomAuthor()
+}
+
+// newRandomMsg creates a new random message from the given author. Code
+// channels sometimes get code samples instead.
+func (msgr *Messenger) newRandomMsg(author message.Author) message.Message {
+	if msgr.channel.code && rand.Intn(3) == 0 {
+		return message.RandomCode(msgr.nextID(), author)
+	}
+
+	var msg = message.RandomWithAuthor(msgr.nextID(), author)
+
+	// Sometimes show off the server's custom emojis.
+	if rand.Intn(8) == 0 {
+		if custom, ok := msgr.channel.parent.Emojis().Random(); ok {
+			msg.SetContent(msg.RawContent() + " :" + custom.Name + ":")
+		}
 	}
 
-	return
+	return msg
 }
 
 func (msgr *Messenger) AsSender() cchat.Sender {
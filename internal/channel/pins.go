@@ -0,0 +1,95 @@
+package channel
+
+import (
+	"github.com/diamondburned/cchat-mock/internal/message"
+	"github.com/pkg/errors"
+)
+
+// Pin pins the message with the given ID. An error is returned if the message
+// doesn't exist or is already pinned.
+func (msgr *Messenger) Pin(id string) error {
+	i, err := message.ParseID(id)
+	if err != nil {
+		return err
+	}
+
+	msgr.messageMutex.Lock()
+	defer msgr.messageMutex.Unlock()
+
+	if _, ok := msgr.messages[i]; !ok {
+		return errors.New("Message not found.")
+	}
+
+	if msgr.isPinned(i) {
+		return errors.New("Message is already pinned.")
+	}
+
+	msgr.pins = append(msgr.pins, i)
+	return nil
+}
+
+// Unpin unpins the message with the given ID. An error is returned if the
+// message isn't pinned.
+func (msgr *Messenger) Unpin(id string) error {
+	i, err := message.ParseID(id)
+	if err != nil {
+		return err
+	}
+
+	msgr.messageMutex.Lock()
+	defer msgr.messageMutex.Unlock()
+
+	if !msgr.unpin(i) {
+		return errors.New("Message is not pinned.")
+	}
+
+	return nil
+}
+
+// IsPinned returns true if the message with the given ID is pinned.
+func (msgr *Messenger) IsPinned(id string) bool {
+	i, err := message.ParseID(id)
+	if err != nil {
+		return false
+	}
+
+	msgr.messageMutex.Lock()
+	defer msgr.messageMutex.Unlock()
+
+	return msgr.isPinned(i)
+}
+
+// Pins returns all pinned messages in the order that they were pinned.
+func (msgr *Messenger) Pins() []message.Message {
+	msgr.messageMutex.Lock()
+	defer msgr.messageMutex.Unlock()
+
+	var pinned = make([]message.Message, 0, len(msgr.pins))
+	for _, id := range msgr.pins {
+		pinned = append(pinned, msgr.messages[id])
+	}
+
+	return pinned
+}
+
+// isPinned must be called with the message mutex acquired.
+func (msgr *Messenger) isPinned(id uint32) bool {
+	for _, pinned := range msgr.pins {
+		if pinned == id {
+			return true
+		}
+	}
+	return false
+}
+
+// unpin removes the ID from the list of pins. It must be called with the
+// message mutex acquired.
+func (msgr *Messenger) unpin(id uint32) bool {
+	for i, pinned := range msgr.pins {
+		if pinned == id {
+			msgr.pins = append(msgr.pins[:i], msgr.pins[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
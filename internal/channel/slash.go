@@ -0,0 +1,223 @@
+package channel
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/diamondburned/cchat"
+	"github.com/diamondburned/cchat-mock/internal/message"
+	"github.com/diamondburned/cchat-mock/segments"
+	"github.com/pkg/errors"
+)
+
+// shrug is the shrug emoticon escaped for Markdown.
+const shrug = `¯\\\_(ツ)\_/¯`
+
+// Dice limits for /roll.
+const (
+	maxDice  = 100
+	maxSides = 1000
+)
+
+// SlashCommand is a command that can be sent as a message starting with a
+// slash, such as "/me waves".
+type SlashCommand struct {
+	Name  string
+	Usage string // argument usage, such as "<message>"
+	Help  string
+
+	run func(msgs MessageSender, msg cchat.SendableMessage, arg string) error
+}
+
+// SlashCommands is the list of commands that the message composer supports.
+var SlashCommands []SlashCommand
+
+func init() {
+	SlashCommands = []SlashCommand{
+		{"me", "<action>", "Send an action", slashMe},
+		{"shrug", "[message]", `Append ¯\_(ツ)_/¯ to the message`, slashShrug},
+		{"nick", "<name>", "Change your nickname in this channel", slashNick},
+		{"topic", "[topic]", "Show or change the channel topic", slashTopic},
+		{"roll", "[NdM]", "Roll N dice with M sides, 1d6 by default", slashRoll},
+		{"clear", "", "Delete all messages that you can delete", slashClear},
+		{"spoiler", "<message>", "Send the message as a spoiler", slashSpoiler},
+	}
+}
+
+// FindSlashCommand returns the command with the given name without the slash.
+func FindSlashCommand(name string) (SlashCommand, bool) {
+	for _, cmd := range SlashCommands {
+		if cmd.Name == name {
+			return cmd, true
+		}
+	}
+	return SlashCommand{}, false
+}
+
+// errUsage is returned by a slash command to show its usage.
+var errUsage = errors.New("invalid usage")
+
+// sentMessage is a message whose content was rewritten by a slash command.
+type sentMessage struct {
+	cchat.SendableMessage
+	content string
+	action  bool
+}
+
+func (msg sentMessage) Content() string {
+	return msg.content
+}
+
+// runSlash runs the slash command in the message content.
+func (msgs MessageSender) runSlash(msg cchat.SendableMessage) error {
+	var content = strings.TrimPrefix(msg.Content(), "/")
+	var name, arg = content, ""
+
+	if i := strings.IndexAny(content, " \t\n"); i >= 0 {
+		name, arg = content[:i], strings.TrimSpace(content[i+1:])
+	}
+
+	cmd, ok := FindSlashCommand(name)
+	if !ok {
+		return errors.Errorf("Unknown command /%s.", name)
+	}
+
+	msgs.msgr.channel.ranCommand(cmd.Name)
+
+	if err := cmd.run(msgs, msg, arg); err != errUsage {
+		return err
+	}
+
+	return errors.Errorf("Usage: /%s %s", cmd.Name, cmd.Usage)
+}
+
+func slashMe(msgs MessageSender, msg cchat.SendableMessage, arg string) error {
+	if arg == "" {
+		return errUsage
+	}
+
+	msgs.queue(sentMessage{msg, arg, true})
+	return nil
+}
+
+func slashShrug(msgs MessageSender, msg cchat.SendableMessage, arg string) error {
+	msgs.queue(sentMessage{msg, strings.TrimSpace(arg + " " + shrug), false})
+	return nil
+}
+
+func slashSpoiler(msgs MessageSender, msg cchat.SendableMessage, arg string) error {
+	if arg == "" {
+		return errUsage
+	}
+
+	msgs.queue(sentMessage{msg, "||" + arg + "||", false})
+	return nil
+}
+
+func slashNick(msgs MessageSender, msg cchat.SendableMessage, arg string) error {
+	if arg == "" {
+		return errUsage
+	}
+
+	var ch = msgs.msgr.channel
+	var old = ch.user.String()
+
+	var name segments.Builder
+	name.Colorful(arg, message.ReadableRGB(0xE88AF8))
+
+	ch.user.Set(Username(name.Rich()))
+	msgs.msgr.typ.SetSelf(ch.self())
+
+	return msgs.msgr.notify(fmt.Sprintf("%s is now known as %s.", old, arg))
+}
+
+func slashTopic(msgs MessageSender, msg cchat.SendableMessage, arg string) error {
+	var ch = msgs.msgr.channel
+
+	if arg == "" {
+		if topic := ch.Topic(); topic != "" {
+			return msgs.msgr.notify("The topic is: " + topic)
+		}
+		return msgs.msgr.notify("No topic is set.")
+	}
+
+	ch.SetTopic(arg)
+	return msgs.msgr.notify(fmt.Sprintf("%s changed the topic to: %s", ch.user, arg))
+}
+
+func slashRoll(msgs MessageSender, msg cchat.SendableMessage, arg string) error {
+	if arg == "" {
+		arg = "1d6"
+	}
+
+	n, m, err := parseDice(arg)
+	if err != nil {
+		return err
+	}
+
+	var rolls = make([]string, n)
+	var total int
+
+	for i := range rolls {
+		roll := rand.Intn(m) + 1
+		rolls[i] = strconv.Itoa(roll)
+		total += roll
+	}
+
+	var result = strconv.Itoa(total)
+	if n > 1 {
+		result = strings.Join(rolls, " + ") + " = " + result
+	}
+
+	return msgs.msgr.notify(fmt.Sprintf(
+		"%s rolled %dd%d: %s", msgs.msgr.channel.user, n, m, result,
+	))
+}
+
+// parseDice parses dice in the NdM format, where N may be omitted.
+func parseDice(dice string) (n, m int, err error) {
+	i := strings.IndexAny(dice, "dD")
+	if i < 0 {
+		return 0, 0, errors.Errorf("Invalid dice %q, expected NdM such as 2d6.", dice)
+	}
+
+	n = 1
+	if i > 0 {
+		if n, err = strconv.Atoi(dice[:i]); err != nil {
+			return 0, 0, errors.Wrap(err, "Invalid number of dice")
+		}
+	}
+
+	if m, err = strconv.Atoi(dice[i+1:]); err != nil {
+		return 0, 0, errors.Wrap(err, "Invalid number of sides")
+	}
+
+	if n < 1 || n > maxDice {
+		return 0, 0, errors.Errorf("Number of dice must be between 1 and %d.", maxDice)
+	}
+	if m < 2 || m > maxSides {
+		return 0, 0, errors.Errorf("Number of sides must be between 2 and %d.", maxSides)
+	}
+
+	return n, m, nil
+}
+
+func slashClear(msgs MessageSender, msg cchat.SendableMessage, arg string) error {
+	var headers []message.Header
+
+	for _, m := range msgs.msgr.Messages() {
+		if msgs.msgr.canDelete(m) {
+			headers = append(headers, m.Header)
+		}
+	}
+
+	for _, header := range headers {
+		if err := msgs.msgr.deleteAsync(header); err != nil {
+			return err
+		}
+	}
+
+	return msgs.msgr.notify(fmt.Sprintf("%s cleared %d messages.", msgs.msgr.channel.user, len(headers)))
+}
@@ -2,6 +2,7 @@ package channel
 
 import (
 	"context"
+	"sync"
 
 	"github.com/diamondburned/cchat"
 	"github.com/diamondburned/cchat-mock/internal/internet"
@@ -19,14 +20,72 @@ func (u Username) Rich() text.Rich {
 	return text.Rich(u)
 }
 
-// Nickname sets the labeler to the nickname. It simulates heavy IO. This
-// function stops as cancel is called in JoinServer, as Nickname is specially
-// for that.
-func (u Username) Nickname(ctx context.Context, labeler cchat.LabelContainer) (func(), error) {
+// Nickname is the current user's changeable nickname in a channel. It is safe
+// for concurrent use.
+type Nickname struct {
+	mutex    sync.Mutex
+	name     Username
+	labelers map[*cchat.LabelContainer]struct{}
+}
+
+var _ cchat.Nicknamer = (*Nickname)(nil)
+
+// NewNickname creates a new nickname with the given initial name.
+func NewNickname(name Username) *Nickname {
+	return &Nickname{
+		name:     name,
+		labelers: map[*cchat.LabelContainer]struct{}{},
+	}
+}
+
+// Username returns the current nickname.
+func (n *Nickname) Username() Username {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	return n.name
+}
+
+func (n *Nickname) String() string {
+	return n.Username().String()
+}
+
+// Set changes the nickname and updates all labels.
+func (n *Nickname) Set(name Username) {
+	n.mutex.Lock()
+	n.name = name
+
+	var labelers = make([]cchat.LabelContainer, 0, len(n.labelers))
+	for labeler := range n.labelers {
+		labelers = append(labelers, *labeler)
+	}
+
+	n.mutex.Unlock()
+
+	for _, labeler := range labelers {
+		labeler.SetLabel(name.Rich())
+	}
+}
+
+// Nickname sets the labeler to the nickname and keeps it updated until the
+// returned callback is called. It simulates heavy IO.
+func (n *Nickname) Nickname(ctx context.Context, labeler cchat.LabelContainer) (func(), error) {
 	if err := internet.SimulateAustralianCtx(ctx); err != nil {
 		return nil, err
 	}
 
-	labeler.SetLabel(text.Rich(u))
-	return func() {}, nil
+	var key = &labeler
+
+	n.mutex.Lock()
+	n.labelers[key] = struct{}{}
+	var name = n.name
+	n.mutex.Unlock()
+
+	labeler.SetLabel(name.Rich())
+
+	return func() {
+		n.mutex.Lock()
+		delete(n.labelers, key)
+		n.mutex.Unlock()
+	}, nil
 }
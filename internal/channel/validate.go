@@ -0,0 +1,45 @@
+package channel
+
+import (
+	"log"
+
+	"github.com/diamondburned/cchat"
+	"github.com/diamondburned/cchat-mock/segments"
+	"github.com/diamondburned/cchat/text"
+)
+
+// ValidateSegments enables validating the segments of every message sent to the
+// frontend. Diagnostics are logged.
+var ValidateSegments = false
+
+// validatingContainer wraps a MessagesContainer and validates all messages that
+// pass through it.
+type validatingContainer struct {
+	cchat.MessagesContainer
+}
+
+// wrapContainer wraps the container if ValidateSegments is true.
+func wrapContainer(ct cchat.MessagesContainer) cchat.MessagesContainer {
+	if ValidateSegments {
+		return validatingContainer{ct}
+	}
+	return ct
+}
+
+func (ct validatingContainer) CreateMessage(msg cchat.MessageCreate) {
+	validate(msg.ID(), "content", msg.Content())
+	validate(msg.ID(), "author", msg.Author().Name())
+	ct.MessagesContainer.CreateMessage(msg)
+}
+
+func (ct validatingContainer) UpdateMessage(msg cchat.MessageUpdate) {
+	validate(msg.ID(), "content", msg.Content())
+	validate(msg.ID(), "author", msg.Author().Name())
+	ct.MessagesContainer.UpdateMessage(msg)
+}
+
+func validate(id, what string, r text.Rich) {
+	for _, diag := range segments.Validate(r) {
+		log.Printf("Message %s has invalid %s segments: %v", id, what, diag)
+	}
+}
@@ -0,0 +1,134 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the type of an argument.
+type Kind uint8
+
+const (
+	// String is any single word.
+	String Kind = iota
+	// Int is an integer.
+	Int
+	// Duration is a duration such as "30s".
+	Duration
+	// Enum is one of the argument's choices.
+	Enum
+	// Rest takes all remaining words. It must be the last argument.
+	Rest
+)
+
+// Arg is a command argument.
+type Arg struct {
+	Name     string
+	Kind     Kind
+	Optional bool
+	// Default is used when an optional argument is not given. It is parsed
+	// like a given word.
+	Default string
+	// Choices is the list of valid words for Enum arguments. It is also used
+	// for completion of other kinds.
+	Choices []string
+	// Complete returns the completion candidates for the word. It is used
+	// instead of Choices if not nil.
+	Complete func(word string) []string
+}
+
+func (arg Arg) usage() string {
+	var name = arg.Name
+	if arg.Kind == Enum {
+		name = strings.Join(arg.Choices, "|")
+	}
+	if arg.Kind == Rest {
+		name += "..."
+	}
+
+	if arg.Optional {
+		return "[" + name + "]"
+	}
+	return "<" + name + ">"
+}
+
+func (arg Arg) parse(word string) (interface{}, error) {
+	switch arg.Kind {
+	case Int:
+		i, err := strconv.Atoi(word)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s %q, expected a number.", arg.Name, word)
+		}
+		return i, nil
+
+	case Duration:
+		d, err := time.ParseDuration(word)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s %q, expected a duration such as 30s.", arg.Name, word)
+		}
+		return d, nil
+
+	case Enum:
+		for _, choice := range arg.Choices {
+			if choice == word {
+				return word, nil
+			}
+		}
+		return nil, fmt.Errorf("Invalid %s %q.", arg.Name, word)
+
+	default:
+		return word, nil
+	}
+}
+
+func (arg Arg) choices(word string) []string {
+	if arg.Complete != nil {
+		return arg.Complete(word)
+	}
+	return append([]string(nil), arg.Choices...)
+}
+
+// Args is the parsed arguments of a command, keyed by name.
+type Args struct {
+	ctx    context.Context
+	values map[string]interface{}
+}
+
+// Context returns the context of the command. Long-running commands should
+// stop when it is done.
+func (args Args) Context() context.Context {
+	return args.ctx
+}
+
+// Has returns true if the argument was given or has a default.
+func (args Args) Has(name string) bool {
+	_, ok := args.values[name]
+	return ok
+}
+
+// String returns the String or Enum argument.
+func (args Args) String(name string) string {
+	s, _ := args.values[name].(string)
+	return s
+}
+
+// Int returns the Int argument.
+func (args Args) Int(name string) int {
+	i, _ := args.values[name].(int)
+	return i
+}
+
+// Duration returns the Duration argument.
+func (args Args) Duration(name string) time.Duration {
+	d, _ := args.values[name].(time.Duration)
+	return d
+}
+
+// Words returns the Rest argument.
+func (args Args) Words(name string) []string {
+	w, _ := args.values[name].([]string)
+	return w
+}
@@ -0,0 +1,338 @@
+// Package command implements a small declarative command registry with
+// subcommands, typed arguments, help text and generated completion.
+package command
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/diamondburned/cchat"
+	"github.com/diamondburned/cchat-mock/stream"
+	"github.com/diamondburned/cchat/text"
+)
+
+// Command is a command or subcommand. A command with subcommands dispatches
+// to them by the next word and doesn't take any arguments itself.
+type Command struct {
+	Name string
+	Help string
+	Args []Arg
+
+	Subcommands []*Command
+
+	// Run runs the command and writes its output into w as it goes. It is nil
+	// for commands that only have subcommands.
+	Run func(w io.Writer, args Args) error
+
+	parent *Command
+}
+
+// Path returns the full name of the command, including its parents.
+func (cmd *Command) Path() string {
+	if cmd.parent == nil {
+		return cmd.Name
+	}
+	return cmd.parent.Path() + " " + cmd.Name
+}
+
+// Usage returns the usage line of the command, such as
+// "random <paragraph|noun> [repeat]".
+func (cmd *Command) Usage() string {
+	var usage = []string{cmd.Path()}
+
+	if len(cmd.Subcommands) > 0 {
+		var names = make([]string, len(cmd.Subcommands))
+		for i, sub := range cmd.Subcommands {
+			names[i] = sub.Name
+		}
+		usage = append(usage, "<"+strings.Join(names, "|")+">")
+	}
+
+	for _, arg := range cmd.Args {
+		usage = append(usage, arg.usage())
+	}
+
+	return strings.Join(usage, " ")
+}
+
+// writeHelp writes the usage and help of the command and its subcommands.
+func (cmd *Command) writeHelp(w io.Writer, indent string) {
+	fmt.Fprintf(w, "%s%s\n", indent, cmd.Usage())
+	if cmd.Help != "" {
+		fmt.Fprintf(w, "%s    %s\n", indent, cmd.Help)
+	}
+
+	for _, sub := range cmd.Subcommands {
+		sub.writeHelp(w, indent+"  ")
+	}
+}
+
+// parse parses the words after the command into its arguments.
+func (cmd *Command) parse(ctx context.Context, words []string) (Args, error) {
+	var args = Args{
+		ctx:    ctx,
+		values: make(map[string]interface{}, len(cmd.Args)),
+	}
+
+	for i, arg := range cmd.Args {
+		if arg.Kind == Rest {
+			if i < len(words) {
+				args.values[arg.Name] = words[i:]
+			} else if !arg.Optional {
+				return args, &UsageError{cmd, "Missing " + arg.Name + "."}
+			}
+			return args, nil
+		}
+
+		var word = arg.Default
+		if i < len(words) {
+			word = words[i]
+		}
+
+		if word == "" {
+			if arg.Optional {
+				continue
+			}
+			return args, &UsageError{cmd, "Missing " + arg.Name + "."}
+		}
+
+		v, err := arg.parse(word)
+		if err != nil {
+			return args, &UsageError{cmd, err.Error()}
+		}
+
+		args.values[arg.Name] = v
+	}
+
+	if len(words) > len(cmd.Args) {
+		return args, &UsageError{cmd, "Too many arguments."}
+	}
+
+	return args, nil
+}
+
+// arg returns the argument at the given position, if any.
+func (cmd *Command) arg(i int) (Arg, bool) {
+	if n := len(cmd.Args); n > 0 && i >= n && cmd.Args[n-1].Kind == Rest {
+		return cmd.Args[n-1], true
+	}
+	if i < len(cmd.Args) {
+		return cmd.Args[i], true
+	}
+	return Arg{}, false
+}
+
+// UsageError is returned when a command is used wrongly.
+type UsageError struct {
+	Command *Command
+	Reason  string
+}
+
+func (err *UsageError) Error() string {
+	if err.Reason == "" {
+		return "Usage: " + err.Command.Usage()
+	}
+	return err.Reason + " Usage: " + err.Command.Usage()
+}
+
+// Registry is a list of commands. It implements the Run and Complete methods
+// of cchat.Commander and cchat.Completer, and it has a built-in help command.
+type Registry struct {
+	commands []*Command
+}
+
+// NewRegistry creates a new registry with the given commands.
+func NewRegistry(cmds ...*Command) *Registry {
+	var r = &Registry{}
+	r.Add(cmds...)
+	r.Add(&Command{
+		Name: "help",
+		Help: "Show the usage of all or the given command",
+		Args: []Arg{{
+			Name:     "command",
+			Kind:     Rest,
+			Optional: true,
+			Complete: func(string) []string { return r.Names() },
+		}},
+		Run: r.help,
+	})
+	return r
+}
+
+// Add adds the commands into the registry.
+func (r *Registry) Add(cmds ...*Command) {
+	for _, cmd := range cmds {
+		setParents(cmd)
+	}
+	r.commands = append(r.commands, cmds...)
+}
+
+func setParents(cmd *Command) {
+	for _, sub := range cmd.Subcommands {
+		sub.parent = cmd
+		setParents(sub)
+	}
+}
+
+// Names returns the names of all top-level commands.
+func (r *Registry) Names() []string {
+	var names = make([]string, len(r.commands))
+	for i, cmd := range r.commands {
+		names[i] = cmd.Name
+	}
+	return names
+}
+
+// Lookup finds the command for the given words. It returns the command and the
+// remaining words after the command and its subcommands.
+func (r *Registry) Lookup(words []string) (*Command, []string, error) {
+	if len(words) == 0 {
+		return nil, nil, fmt.Errorf("Missing command. Commands: %s", strings.Join(r.Names(), ", "))
+	}
+
+	var cmd = find(r.commands, words[0])
+	if cmd == nil {
+		return nil, nil, fmt.Errorf("Unknown command: %q", words[0])
+	}
+
+	words = words[1:]
+
+	for len(cmd.Subcommands) > 0 {
+		if len(words) == 0 {
+			return nil, nil, &UsageError{Command: cmd}
+		}
+
+		var sub = find(cmd.Subcommands, words[0])
+		if sub == nil {
+			return nil, nil, &UsageError{cmd, fmt.Sprintf("Unknown subcommand %q.", words[0])}
+		}
+
+		cmd, words = sub, words[1:]
+	}
+
+	return cmd, words, nil
+}
+
+var _ stream.Streamer = (*Registry)(nil)
+
+// Run runs the command for the given words and returns its output.
+func (r *Registry) Run(words []string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := r.RunContext(context.Background(), words, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RunContext runs the command for the given words and writes its output into
+// w.
+func (r *Registry) RunContext(ctx context.Context, words []string, w io.Writer) error {
+	cmd, words, err := r.Lookup(words)
+	if err != nil {
+		return err
+	}
+
+	args, err := cmd.parse(ctx, words)
+	if err != nil {
+		return err
+	}
+
+	return cmd.Run(w, args)
+}
+
+func (r *Registry) help(w io.Writer, args Args) error {
+	if words := args.Words("command"); len(words) > 0 {
+		cmd := find(r.commands, words[0])
+		if cmd == nil {
+			return fmt.Errorf("Unknown command: %q", words[0])
+		}
+
+		// Narrow down to the subcommand if possible.
+		for _, word := range words[1:] {
+			sub := find(cmd.Subcommands, word)
+			if sub == nil {
+				break
+			}
+			cmd = sub
+		}
+
+		cmd.writeHelp(w, "")
+		return nil
+	}
+
+	for _, cmd := range r.commands {
+		cmd.writeHelp(w, "")
+	}
+
+	return nil
+}
+
+// Complete completes command names, subcommand names and arguments for the
+// word at the given index.
+func (r *Registry) Complete(words []string, i int64) []cchat.CompletionEntry {
+	var level = r.commands
+	var cmd *Command
+	var depth int
+
+	for depth < int(i) && len(level) > 0 {
+		if cmd = find(level, words[depth]); cmd == nil {
+			return nil
+		}
+		level = cmd.Subcommands
+		depth++
+	}
+
+	var word = words[i]
+	var entries []cchat.CompletionEntry
+
+	// Complete a command or subcommand name.
+	if len(level) > 0 {
+		for _, cmd := range level {
+			if strings.HasPrefix(cmd.Name, word) {
+				entries = append(entries, cchat.CompletionEntry{
+					Raw:       cmd.Name,
+					Text:      text.Plain(cmd.Name),
+					Secondary: text.Plain(cmd.Help),
+				})
+			}
+		}
+		return entries
+	}
+
+	arg, ok := cmd.arg(int(i) - depth)
+	if !ok {
+		return nil
+	}
+
+	var choices = arg.choices(word)
+	sort.Strings(choices)
+
+	for i, choice := range choices {
+		if i > 0 && choices[i-1] == choice {
+			continue
+		}
+
+		if strings.HasPrefix(choice, word) {
+			entries = append(entries, cchat.CompletionEntry{
+				Raw:       choice,
+				Text:      text.Plain(choice),
+				Secondary: text.Plain(arg.Name),
+			})
+		}
+	}
+
+	return entries
+}
+
+func find(cmds []*Command, name string) *Command {
+	for _, cmd := range cmds {
+		if cmd.Name == name {
+			return cmd
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+// Package complete implements fuzzy completion ranked by match quality and
+// recency.
+package complete
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/cchat"
+	"github.com/diamondburned/cchat/text"
+)
+
+// recencyWindow is the time after which a candidate stops getting a recency
+// bonus.
+const recencyWindow = 15 * time.Minute
+
+// Candidate is a possible completion.
+type Candidate struct {
+	// Name is matched against the query.
+	Name string
+	// Raw is the text that replaces the completed word.
+	Raw       string
+	Text      text.Rich
+	Secondary text.Rich
+	IconURL   string
+	Image     bool
+	// Time is the last time the candidate was active, such as when a member
+	// last spoke. It may be zero.
+	Time time.Time
+}
+
+// Score scores how well the query fuzzily matches the name, ignoring case.
+// False is returned if the query isn't a subsequence of the name. Exact matches
+// score highest, followed by prefixes, substrings and subsequences, with
+// shorter names and tighter subsequences ranking higher.
+func Score(query, name string) (int, bool) {
+	query = strings.ToLower(query)
+	name = strings.ToLower(name)
+
+	switch {
+	case query == name:
+		return 1000, true
+	case strings.HasPrefix(name, query):
+		return 800 - len(name), true
+	case strings.Contains(name, query):
+		return 600 - len(name), true
+	}
+
+	var gaps, last = 0, -1
+
+	for i := 0; i < len(query); i++ {
+		j := strings.IndexByte(name[last+1:], query[i])
+		if j < 0 {
+			return 0, false
+		}
+		gaps += j
+		last += j + 1
+	}
+
+	return 400 - gaps*10 - len(name), true
+}
+
+// recencyBonus returns a bonus of up to 150 points that decays over the
+// recency window.
+func recencyBonus(t time.Time, now time.Time) int {
+	if t.IsZero() {
+		return 0
+	}
+
+	var age = now.Sub(t)
+	if age < 0 {
+		age = 0
+	}
+	if age > recencyWindow {
+		return 0
+	}
+
+	return int(150 * math.Exp(-3*float64(age)/float64(recencyWindow)))
+}
+
+// Rank returns up to max entries from the candidates that match the query,
+// ranked by match quality and recency.
+func Rank(query string, candidates []Candidate, max int) []cchat.CompletionEntry {
+	type scored struct {
+		Candidate
+		score int
+	}
+
+	var now = time.Now()
+	var matches []scored
+
+	for _, cand := range candidates {
+		if score, ok := Score(query, cand.Name); ok {
+			matches = append(matches, scored{cand, score + recencyBonus(cand.Time, now)})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].Name < matches[j].Name
+	})
+
+	if len(matches) > max {
+		matches = matches[:max]
+	}
+
+	var entries = make([]cchat.CompletionEntry, len(matches))
+	for i, match := range matches {
+		entries[i] = cchat.CompletionEntry{
+			Raw:       match.Raw,
+			Text:      match.Text,
+			Secondary: match.Secondary,
+			IconURL:   match.IconURL,
+			Image:     match.Image,
+		}
+	}
+
+	return entries
+}
@@ -0,0 +1,122 @@
+// Package emoji provides Unicode emoji shortcodes and custom image emojis.
+package emoji
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/diamondburned/aqs"
+)
+
+// Custom is a custom emoji backed by an image.
+type Custom struct {
+	Name string
+	URL  string
+}
+
+// Set is a set of custom emojis, typically belonging to a single server. A nil
+// Set is an empty set.
+type Set struct {
+	customs map[string]Custom
+	names   []string // sorted
+}
+
+// NewSet creates a new set of custom emojis.
+func NewSet(customs ...Custom) *Set {
+	var set = &Set{
+		customs: make(map[string]Custom, len(customs)),
+		names:   make([]string, 0, len(customs)),
+	}
+
+	for _, custom := range customs {
+		if _, ok := set.customs[custom.Name]; ok {
+			continue
+		}
+		set.customs[custom.Name] = custom
+		set.names = append(set.names, custom.Name)
+	}
+
+	sort.Strings(set.names)
+	return set
+}
+
+// RandomSet creates a set of up to n custom emojis made from random character
+// images.
+func RandomSet(n int) *Set {
+	var customs = make([]Custom, 0, n)
+
+	for i := 0; i < n; i++ {
+		char := aqs.RandomCharacter()
+		if char.ImageURL == "" {
+			continue
+		}
+
+		name := shortcode(char.Name)
+		if name == "" {
+			continue
+		}
+
+		customs = append(customs, Custom{
+			Name: name,
+			URL:  char.ImageURL,
+		})
+	}
+
+	return NewSet(customs...)
+}
+
+// shortcode turns a name into a lower-cased shortcode of its first word.
+func shortcode(name string) string {
+	if fields := strings.Fields(name); len(fields) > 0 {
+		name = fields[0]
+	}
+
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		default:
+			return -1
+		}
+	}, name)
+}
+
+// Custom returns the custom emoji with the given name.
+func (s *Set) Custom(name string) (Custom, bool) {
+	if s == nil {
+		return Custom{}, false
+	}
+	c, ok := s.customs[name]
+	return c, ok
+}
+
+// Names returns the sorted names of all custom emojis in the set.
+func (s *Set) Names() []string {
+	if s == nil {
+		return nil
+	}
+	return s.names
+}
+
+// Random returns a random custom emoji. False is returned if the set is empty.
+func (s *Set) Random() (Custom, bool) {
+	if s == nil || len(s.names) == 0 {
+		return Custom{}, false
+	}
+	return s.customs[s.names[rand.Intn(len(s.names))]], true
+}
+
+// RandomUnicode returns a random Unicode shortcode without the colons.
+func RandomUnicode() string {
+	var i = rand.Intn(len(Unicode))
+	for name := range Unicode {
+		if i == 0 {
+			return name
+		}
+		i--
+	}
+	return "smile"
+}
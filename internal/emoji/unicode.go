@@ -0,0 +1,83 @@
+package emoji
+
+// Unicode maps shortcodes, without the colons, to Unicode emojis.
+var Unicode = map[string]string{
+	"smile":            "😄",
+	"smiley":           "😃",
+	"grin":             "😁",
+	"joy":              "🤣",
+	"laughing":         "😆",
+	"sweat_smile":      "😅",
+	"wink":             "😉",
+	"blush":            "😊",
+	"innocent":         "😇",
+	"heart_eyes":       "😍",
+	"kissing_heart":    "😘",
+	"yum":              "😋",
+	"stuck_out_tongue": "😛",
+	"thinking":         "🤔",
+	"neutral_face":     "😐",
+	"expressionless":   "😑",
+	"unamused":         "😒",
+	"rolling_eyes":     "🙄",
+	"grimacing":        "😬",
+	"relieved":         "😌",
+	"pensive":          "😔",
+	"sleepy":           "😪",
+	"sleeping":         "😴",
+	"sunglasses":       "😎",
+	"nerd":             "🤓",
+	"confused":         "😕",
+	"worried":          "😟",
+	"open_mouth":       "😮",
+	"flushed":          "😳",
+	"pleading_face":    "🥺",
+	"cry":              "😢",
+	"sob":              "😭",
+	"scream":           "😱",
+	"angry":            "😠",
+	"rage":             "😡",
+	"skull":            "💀",
+	"clown":            "🤡",
+	"ghost":            "👻",
+	"alien":            "👽",
+	"robot":            "🤖",
+	"cat":              "🐱",
+	"dog":              "🐶",
+	"fox":              "🦊",
+	"thumbsup":         "👍",
+	"thumbsdown":       "👎",
+	"ok_hand":          "👌",
+	"clap":             "👏",
+	"wave":             "👋",
+	"pray":             "🙏",
+	"muscle":           "💪",
+	"eyes":             "👀",
+	"heart":            "❤️",
+	"orange_heart":     "🧡",
+	"yellow_heart":     "💛",
+	"green_heart":      "💚",
+	"blue_heart":       "💙",
+	"purple_heart":     "💜",
+	"broken_heart":     "💔",
+	"sparkles":         "✨",
+	"star":             "⭐",
+	"fire":             "🔥",
+	"100":              "💯",
+	"tada":             "🎉",
+	"rainbow":          "🌈",
+	"rainbow_flag":     "🏳️‍🌈",
+	"transgender_flag": "🏳️‍⚧️",
+	"coffee":           "☕",
+	"pizza":            "🍕",
+	"cake":             "🍰",
+	"rocket":           "🚀",
+	"warning":          "⚠️",
+	"white_check_mark": "✅",
+	"x":                "❌",
+	"question":         "❓",
+	"zzz":              "💤",
+	"shrug":            "🤷",
+	"facepalm":         "🤦",
+	"family":           "👨‍👩‍👧‍👦",
+}
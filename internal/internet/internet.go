@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"math/rand"
+	"sync/atomic"
 	"time"
 )
 
@@ -18,6 +19,24 @@ var (
 // ErrTimedOut is returned when the simulated IO decides to fail.
 var ErrTimedOut = errors.New("Australian Internet unsupported.")
 
+// ErrOffline is returned when the simulated network is offline.
+var ErrOffline = errors.New("Network is offline.")
+
+// offlineUntil is the Unix time in nanoseconds until which the network is
+// offline.
+var offlineUntil int64
+
+// GoOffline makes all simulated IO fail with ErrOffline for the given duration.
+func GoOffline(d time.Duration) {
+	atomic.StoreInt64(&offlineUntil, time.Now().Add(d).UnixNano())
+}
+
+// OfflineUntil returns the time until which the network is offline. The
+// returned time is in the past if the network is online.
+func OfflineUntil() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&offlineUntil))
+}
+
 // SimulateAustralian simulates network latency with errors.
 func SimulateAustralian() error {
 	return SimulateAustralianCtx(context.Background())
@@ -34,6 +53,10 @@ func SimulateAustralianCtx(ctx context.Context) (err error) {
 		return ctx.Err()
 	}
 
+	if time.Now().Before(OfflineUntil()) {
+		return ErrOffline
+	}
+
 	// because australia, drop packet 20% of the time if internetCanFail is
 	// true.
 	if CanFail && rand.Intn(100) < 20 {
@@ -44,5 +67,8 @@ func SimulateAustralianCtx(ctx context.Context) (err error) {
 }
 
 func randClamp(min, max int) int {
+	if max <= min {
+		return min
+	}
 	return rand.Intn(max-min) + min
 }
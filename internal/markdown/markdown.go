@@ -0,0 +1,432 @@
+// Package markdown parses a small Markdown-like syntax into cchat rich text.
+//
+// The supported syntax is:
+//
+//	**bold**, *italics* or _italics_, __underline__, ~~strikethrough~~,
+//	`inline code`, ||spoiler||, [link text](https://example.com),
+//	> block quotes and fenced ```language code blocks```.
+//
+// Images are written as ![alt text](https://example.com/image.png =WxH), where
+// the size is optional. Bare URLs are automatically turned into links, and bare
+// image URLs also get an inline image.
+//
+// Emoji shortcodes such as :smile: are replaced with their Unicode emojis, and
+// custom emojis from the set given to ParseWithEmojis are rendered as images.
+//
+// Markup is stripped from the returned content, and segments are nested the
+// same way the markup is.
+package markdown
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/diamondburned/cchat-mock/internal/emoji"
+	"github.com/diamondburned/cchat-mock/segments"
+	"github.com/diamondburned/cchat/text"
+)
+
+// EmojiSize is the requested size of custom emoji images.
+const EmojiSize = 32
+
+// Parse parses the given Markdown source into rich text.
+func Parse(src string) text.Rich {
+	return ParseWithEmojis(src, nil)
+}
+
+// ParseWithEmojis parses the given Markdown source into rich text, rendering
+// custom emojis from the given set. The set may be nil.
+func ParseWithEmojis(src string, emojis *emoji.Set) text.Rich {
+	var r = renderer{emojis: emojis}
+	r.blocks(src)
+
+	return r.Rich()
+}
+
+type renderer struct {
+	segments.Builder
+	emojis *emoji.Set
+	// inLink is true when rendering a link's label, so URLs inside it aren't
+	// linked again.
+	inLink bool
+}
+
+// add adds the segment if it's not empty.
+func (r *renderer) add(seg text.Segment) {
+	if start, end := seg.Bounds(); start < end {
+		r.Add(seg)
+	}
+}
+
+func (r *renderer) blocks(src string) {
+	var lines = strings.Split(src, "\n")
+
+	for i := 0; i < len(lines); i++ {
+		if i > 0 {
+			r.WriteByte('\n')
+		}
+
+		var line = lines[i]
+
+		switch {
+		case strings.HasPrefix(line, "```"):
+			end := closingFence(lines, i+1)
+			if end == -1 {
+				break
+			}
+
+			var start = r.Len()
+			r.Write(strings.Join(lines[i+1:end], "\n"))
+			r.add(segments.NewCodeblockSegment(
+				start, r.Len(), strings.TrimSpace(line[3:]),
+			))
+
+			i = end
+			continue
+
+		case isQuote(line):
+			var start = r.Len()
+			r.inline(trimQuote(line))
+
+			for i+1 < len(lines) && isQuote(lines[i+1]) {
+				i++
+				r.WriteByte('\n')
+				r.inline(trimQuote(lines[i]))
+			}
+
+			r.add(segments.NewQuoteSegment(start, r.Len(), ">"))
+			continue
+		}
+
+		r.inline(line)
+	}
+}
+
+// closingFence returns the index of the line that closes a code block, or -1
+// if there is none.
+func closingFence(lines []string, from int) int {
+	for i := from; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "```" {
+			return i
+		}
+	}
+	return -1
+}
+
+func isQuote(line string) bool {
+	return strings.HasPrefix(line, ">")
+}
+
+func trimQuote(line string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(line, ">"), " ")
+}
+
+func (r *renderer) inline(s string) {
+	for i := 0; i < len(s); {
+		if n := r.markup(s, i); n > 0 {
+			i += n
+			continue
+		}
+
+		r.WriteByte(s[i])
+		i++
+	}
+}
+
+// markup renders the markup at s[i:] if there is any and returns the number of
+// bytes consumed. It returns 0 if s[i:] doesn't start with valid markup.
+func (r *renderer) markup(s string, i int) int {
+	switch s[i] {
+	case '\\':
+		if i+1 < len(s) && isPunct(s[i+1]) {
+			r.WriteByte(s[i+1])
+			return 2
+		}
+	case '`':
+		return r.code(s, i)
+	case '|':
+		return r.wrap(s, i, "||", text.AttributeSpoiler)
+	case '~':
+		return r.wrap(s, i, "~~", text.AttributeStrikethrough)
+	case '*':
+		if n := r.wrap(s, i, "**", text.AttributeBold); n > 0 {
+			return n
+		}
+		return r.wrap(s, i, "*", text.AttributeItalics)
+	case '_':
+		// Don't italicize snake_case words.
+		if i > 0 && isWord(s[i-1]) {
+			return 0
+		}
+		if n := r.wrap(s, i, "__", text.AttributeUnderline); n > 0 {
+			return n
+		}
+		return r.wrap(s, i, "_", text.AttributeItalics)
+	case '[':
+		return r.link(s, i)
+	case '!':
+		return r.image(s, i)
+	case ':':
+		return r.emoji(s, i)
+	case 'h':
+		if !r.inLink && (i == 0 || !isWord(s[i-1])) {
+			return r.autolink(s, i)
+		}
+	}
+
+	return 0
+}
+
+// wrap renders s[i:] if it's surrounded by the given delimiter.
+func (r *renderer) wrap(s string, i int, delim string, attr text.Attribute) int {
+	if !strings.HasPrefix(s[i:], delim) {
+		return 0
+	}
+
+	var inner = s[i+len(delim):]
+
+	end := closingDelim(inner, delim)
+	if end < 1 || inner[0] == ' ' || inner[end-1] == ' ' {
+		return 0
+	}
+
+	var start = r.Len()
+	r.inline(inner[:end])
+	r.add(segments.NewAttributeSegment(start, r.Len(), attr))
+
+	return end + len(delim)*2
+}
+
+// closingDelim finds the closing delimiter. Single-character delimiters don't
+// close on doubled ones, so "*a **b** c*" works. Doubled delimiters skip over
+// inner single ones that are closed, so "**a *b** c*" isn't bold.
+func closingDelim(s, delim string) int {
+	var c = delim[0]
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != c {
+			continue
+		}
+
+		if len(delim) > 1 {
+			if strings.HasPrefix(s[i:], delim) {
+				return i
+			}
+			if c == '*' || c == '_' {
+				if end := closingDelim(s[i+1:], delim[:1]); end > -1 {
+					i += 1 + end // skip the inner span
+				}
+			}
+			continue
+		}
+
+		if i+1 < len(s) && s[i+1] == c {
+			i++ // skip the doubled delimiter
+			continue
+		}
+		return i
+	}
+
+	return -1
+}
+
+// code renders an inline code span delimited by a run of backticks.
+func (r *renderer) code(s string, i int) int {
+	var n = i
+	for n < len(s) && s[n] == '`' {
+		n++
+	}
+
+	var ticks = s[i:n]
+
+	end := strings.Index(s[n:], ticks)
+	if end < 1 {
+		// Write the whole run literally, so a shorter run isn't matched.
+		r.Write(ticks)
+		return len(ticks)
+	}
+
+	var inner = s[n : n+end]
+	if len(inner) > 2 && inner[0] == ' ' && inner[len(inner)-1] == ' ' {
+		inner = inner[1 : len(inner)-1]
+	}
+
+	var start = r.Len()
+	r.Write(inner)
+	r.add(segments.NewAttributeSegment(start, r.Len(), text.AttributeMonospace))
+
+	return end + len(ticks)*2
+}
+
+// link renders a [text](url) hyperlink.
+func (r *renderer) link(s string, i int) int {
+	mid := strings.Index(s[i:], "](")
+	if mid < 0 {
+		return 0
+	}
+
+	var label = s[i+1 : i+mid]
+	var rest = s[i+mid+2:]
+
+	end := strings.IndexByte(rest, ')')
+	if end < 1 || strings.ContainsRune(rest[:end], ' ') {
+		return 0
+	}
+
+	r.linked(label, rest[:end])
+
+	return mid + 2 + end + 1
+}
+
+// linked renders the label as a link to the given URL.
+func (r *renderer) linked(label, url string) {
+	var start = r.Len()
+
+	r.inLink = true
+	r.inline(label)
+	r.inLink = false
+
+	r.add(segments.NewLinkSegment(start, r.Len(), url))
+}
+
+// image renders an ![alt](url =WxH) image. The alt text is rendered as a link
+// to the image, followed by a zero-length image segment.
+func (r *renderer) image(s string, i int) int {
+	if !strings.HasPrefix(s[i:], "![") {
+		return 0
+	}
+
+	mid := strings.Index(s[i:], "](")
+	if mid < 0 {
+		return 0
+	}
+
+	var alt = s[i+2 : i+mid]
+	var rest = s[i+mid+2:]
+
+	end := strings.IndexByte(rest, ')')
+	if end < 1 {
+		return 0
+	}
+
+	url, w, h := parseImageTarget(rest[:end])
+	if url == "" || strings.ContainsRune(url, ' ') {
+		return 0
+	}
+
+	r.linked(alt, url)
+	r.Image(url, alt, w, h)
+
+	return mid + 2 + end + 1
+}
+
+// emoji renders an emoji shortcode.
+func (r *renderer) emoji(s string, i int) int {
+	var end = i + 1
+	for end < len(s) && isShortcode(s[end]) {
+		end++
+	}
+
+	if end == i+1 || end >= len(s) || s[end] != ':' {
+		return 0
+	}
+
+	var name = s[i+1 : end]
+
+	if unicode, ok := emoji.Unicode[name]; ok {
+		r.Write(unicode)
+		return end - i + 1
+	}
+
+	if custom, ok := r.emojis.Custom(name); ok {
+		var code = s[i : end+1]
+		var span = r.Write(code)
+		r.Add(segments.NewImageSegment(
+			span.Start, span.End, custom.URL, code, EmojiSize, EmojiSize,
+		))
+		return end - i + 1
+	}
+
+	return 0
+}
+
+func isShortcode(b byte) bool {
+	return isWord(b) || b == '+' || b == '-'
+}
+
+// parseImageTarget parses "url =WxH" into the URL and its optional size.
+func parseImageTarget(target string) (url string, w, h int) {
+	parts := strings.SplitN(target, " =", 2)
+	if len(parts) == 2 {
+		size := strings.SplitN(parts[1], "x", 2)
+		if len(size) == 2 {
+			w, _ = strconv.Atoi(size[0])
+			h, _ = strconv.Atoi(size[1])
+		}
+	}
+
+	return parts[0], w, h
+}
+
+// autolink renders a bare http or https URL as a link. Image URLs also get a
+// zero-length image segment after them.
+func (r *renderer) autolink(s string, i int) int {
+	if !strings.HasPrefix(s[i:], "http://") && !strings.HasPrefix(s[i:], "https://") {
+		return 0
+	}
+
+	var end = strings.IndexAny(s[i:], " \t\n")
+	if end < 0 {
+		end = len(s) - i
+	}
+
+	// Trailing punctuation is most likely part of the sentence.
+	var url = strings.TrimRight(s[i:i+end], ".,;:!?)'\"")
+
+	// Require a host, so a lone "https://" isn't linked.
+	var host = url[strings.Index(url, "://")+3:]
+	if n := strings.IndexAny(host, "/?#"); n > -1 {
+		host = host[:n]
+	}
+	if host == "" {
+		return 0
+	}
+
+	r.Linked(url, url)
+	if IsImageURL(url) {
+		r.Image(url, "", 0, 0)
+	}
+
+	return len(url)
+}
+
+// imageExts is the list of file extensions that IsImageURL recognizes.
+var imageExts = []string{".png", ".jpg", ".jpeg", ".gif", ".webp"}
+
+// IsImageURL returns true if the URL's path looks like an image.
+func IsImageURL(url string) bool {
+	if i := strings.IndexAny(url, "?#"); i > -1 {
+		url = url[:i]
+	}
+
+	url = strings.ToLower(url)
+
+	for _, ext := range imageExts {
+		if strings.HasSuffix(url, ext) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isPunct(b byte) bool {
+	return strings.IndexByte("\\`*_~|[]()>#:!", b) > -1
+}
+
+func isWord(b byte) bool {
+	return b == '_' ||
+		(b >= '0' && b <= '9') ||
+		(b >= 'a' && b <= 'z') ||
+		(b >= 'A' && b <= 'Z')
+}
@@ -0,0 +1,214 @@
+package markdown
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/diamondburned/cchat-mock/internal/emoji"
+	"github.com/diamondburned/cchat-mock/segments"
+	"github.com/diamondburned/cchat/text"
+)
+
+func attr(start, end int, attr text.Attribute) text.Segment {
+	return segments.NewAttributeSegment(start, end, attr)
+}
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		name     string
+		src      string
+		content  string
+		segments []text.Segment
+	}{
+		{
+			name:    "plain",
+			src:     "hello world",
+			content: "hello world",
+		},
+		{
+			name:     "bold and italics",
+			src:      "**bold** and *it*",
+			content:  "bold and it",
+			segments: []text.Segment{attr(0, 4, text.AttributeBold), attr(9, 11, text.AttributeItalics)},
+		},
+		{
+			name:    "underline, strikethrough and spoiler",
+			src:     "__u__ ~~s~~ ||sp||",
+			content: "u s sp",
+			segments: []text.Segment{
+				attr(0, 1, text.AttributeUnderline),
+				attr(2, 3, text.AttributeStrikethrough),
+				attr(4, 6, text.AttributeSpoiler),
+			},
+		},
+		{
+			name:     "italics in bold",
+			src:      "**a *b* c**",
+			content:  "a b c",
+			segments: []text.Segment{attr(2, 3, text.AttributeItalics), attr(0, 5, text.AttributeBold)},
+		},
+		{
+			name:     "bold in italics",
+			src:      "*a **b** c*",
+			content:  "a b c",
+			segments: []text.Segment{attr(2, 3, text.AttributeBold), attr(0, 5, text.AttributeItalics)},
+		},
+		{
+			name:     "bold doesn't close inside italics",
+			src:      "**a *b** c*",
+			content:  "**a b** c",
+			segments: []text.Segment{attr(4, 9, text.AttributeItalics)},
+		},
+		{
+			name:    "unclosed",
+			src:     "*a",
+			content: "*a",
+		},
+		{
+			name:    "space after opener",
+			src:     "** a**",
+			content: "** a**",
+		},
+		{
+			name:    "snake case",
+			src:     "snake_case_word",
+			content: "snake_case_word",
+		},
+		{
+			name:    "escapes",
+			src:     `\*not\*`,
+			content: "*not*",
+		},
+		{
+			name:    "inline code",
+			src:     "`code` ``a`b``",
+			content: "code a`b",
+			segments: []text.Segment{
+				attr(0, 4, text.AttributeMonospace),
+				attr(5, 8, text.AttributeMonospace),
+			},
+		},
+		{
+			name:    "no markup in code",
+			src:     "`*a*`",
+			content: "*a*",
+			segments: []text.Segment{
+				attr(0, 3, text.AttributeMonospace),
+			},
+		},
+		{
+			name:     "link",
+			src:      "[text](https://example.com)",
+			content:  "text",
+			segments: []text.Segment{segments.NewLinkSegment(0, 4, "https://example.com")},
+		},
+		{
+			name:    "link with space",
+			src:     "[bad](no space)",
+			content: "[bad](no space)",
+		},
+		{
+			name:    "image",
+			src:     "![alt](https://example.com/i.png =10x20)",
+			content: "alt",
+			segments: []text.Segment{
+				segments.NewLinkSegment(0, 3, "https://example.com/i.png"),
+				segments.NewImageSegment(3, 3, "https://example.com/i.png", "alt", 10, 20),
+			},
+		},
+		{
+			name:     "autolink",
+			src:      "go to https://example.com.",
+			content:  "go to https://example.com.",
+			segments: []text.Segment{segments.NewLinkSegment(6, 25, "https://example.com")},
+		},
+		{
+			name:    "autolink image",
+			src:     "https://example.com/a.png",
+			content: "https://example.com/a.png",
+			segments: []text.Segment{
+				segments.NewLinkSegment(0, 25, "https://example.com/a.png"),
+				segments.NewImageSegment(25, 25, "https://example.com/a.png", "", 0, 0),
+			},
+		},
+		{
+			name:    "autolink without host",
+			src:     "see https://.",
+			content: "see https://.",
+		},
+		{
+			name:    "autolink without anything",
+			src:     "https://",
+			content: "https://",
+		},
+		{
+			name:     "quote",
+			src:      "> quote\n> more\nafter",
+			content:  "quote\nmore\nafter",
+			segments: []text.Segment{segments.NewQuoteSegment(0, 10, ">")},
+		},
+		{
+			name:     "code block",
+			src:      "```go\n*a*\n```",
+			content:  "*a*",
+			segments: []text.Segment{segments.NewCodeblockSegment(0, 3, "go")},
+		},
+		{
+			name:    "unclosed code block",
+			src:     "```go\na",
+			content: "```go\na",
+		},
+		{
+			name:    "emoji",
+			src:     ":smile: :nope:",
+			content: "😄 :nope:",
+		},
+	}
+
+	for _, test := range tests {
+		var r = Parse(test.src)
+
+		if r.Content != test.content {
+			t.Errorf("%s: content = %q, want %q", test.name, r.Content, test.content)
+		}
+		if !reflect.DeepEqual(r.Segments, test.segments) {
+			t.Errorf("%s: segments = %#v, want %#v", test.name, r.Segments, test.segments)
+		}
+	}
+}
+
+func TestParseWithEmojis(t *testing.T) {
+	var set = emoji.NewSet(emoji.Custom{Name: "astolfo", URL: "https://example.com/astolfo.png"})
+	var r = ParseWithEmojis("hi :astolfo:", set)
+
+	if r.Content != "hi :astolfo:" {
+		t.Errorf("content = %q, want %q", r.Content, "hi :astolfo:")
+	}
+
+	var want = []text.Segment{segments.NewImageSegment(
+		3, 12, "https://example.com/astolfo.png", ":astolfo:", EmojiSize, EmojiSize,
+	)}
+	if !reflect.DeepEqual(r.Segments, want) {
+		t.Errorf("segments = %#v, want %#v", r.Segments, want)
+	}
+}
+
+func TestIsImageURL(t *testing.T) {
+	var tests = []struct {
+		url   string
+		image bool
+	}{
+		{"https://example.com/a.png", true},
+		{"https://example.com/a.JPG", true},
+		{"https://example.com/a.gif?size=64", true},
+		{"https://example.com/a.webp#frag", true},
+		{"https://example.com/a.png/", false},
+		{"https://example.com/", false},
+	}
+
+	for _, test := range tests {
+		if image := IsImageURL(test.url); image != test.image {
+			t.Errorf("IsImageURL(%q) = %v, want %v", test.url, image, test.image)
+		}
+	}
+}
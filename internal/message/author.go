@@ -1,10 +1,13 @@
 package message
 
 import (
+	"math/rand"
+
 	"github.com/diamondburned/aqs"
 	"github.com/diamondburned/cchat"
 	"github.com/diamondburned/cchat-mock/segments"
 	"github.com/diamondburned/cchat/text"
+	"github.com/lucasb-eyer/go-colorful"
 )
 
 const AvatarURL = "" +
@@ -13,6 +16,33 @@ const AvatarURL = "" +
 	"598069da673093aaca4cd4aa0ede1a0e324e9a3a/" +
 	"astolfo_selfie.png"
 
+// Background is the background that author name colors are adjusted to be
+// readable against. It is either "dark", "light" or a hex color; refer to
+// segments.ParseBackground.
+var Background = "dark"
+
+// Readable adjusts the color to be readable against the Background.
+func Readable(c colorful.Color) colorful.Color {
+	bg, err := segments.ParseBackground(Background)
+	if err != nil {
+		bg = segments.DarkBackground
+	}
+
+	return segments.EnsureContrast(c, bg, segments.MinContrast)
+}
+
+// ReadableRGB is Readable for 24-bit RGB colors.
+func ReadableRGB(rgb uint32) colorful.Color {
+	return Readable(colorful.Color{
+		R: float64((rgb>>16)&0xFF) / 255,
+		G: float64((rgb>>8)&0xFF) / 255,
+		B: float64(rgb&0xFF) / 255,
+	})
+}
+
+// SystemAuthor is the author of messages sent by the mock backend itself.
+var SystemAuthor = NewAuthor(text.Plain("cchat-mock"))
+
 type Author struct {
 	name text.Rich
 	char aqs.Character
@@ -24,16 +54,35 @@ func NewAuthor(name text.Rich) Author {
 	return Author{name: name}
 }
 
+// RandomAuthor returns an author made from a random character. If
+// StressUnicode is true, then the author sometimes has a tricky name instead.
 func RandomAuthor() Author {
+	if StressUnicode && rand.Intn(3) == 0 {
+		return RandomStressAuthor()
+	}
+	return randomCharAuthor()
+}
+
+func randomCharAuthor() Author {
 	var char = aqs.RandomCharacter()
+
+	var name segments.Builder
+
+	// Give some authors a fancy gradient name.
+	if rand.Intn(6) == 0 {
+		stops := append([]colorful.Color{char.NameColor()}, segments.RandomGradient()...)
+		for i, stop := range stops {
+			stops[i] = Readable(stop)
+		}
+
+		name.Gradient(char.Name, stops...)
+	} else {
+		name.Colorful(char.Name, Readable(char.NameColor()))
+	}
+
 	return Author{
 		char: char,
-		name: text.Rich{
-			Content: char.Name,
-			Segments: []text.Segment{
-				segments.NewColorfulSegment(char.Name, char.NameColor()),
-			},
-		},
+		name: name.Rich(),
 	}
 }
 
@@ -45,6 +94,13 @@ func (a Author) Name() text.Rich {
 	return a.name
 }
 
+// HasCharacter returns true if the author was made from a character, which
+// random messages need for their content. The system author and authors made
+// with NewAuthor don't have one.
+func (a Author) HasCharacter() bool {
+	return a.char.Name != ""
+}
+
 func (a Author) Avatar() string {
 	if a.char.ImageURL != "" {
 		return a.char.ImageURL
@@ -0,0 +1,139 @@
+package message
+
+import (
+	"math/rand"
+	"time"
+)
+
+// CodeSample is a snippet of code in a language.
+type CodeSample struct {
+	Language string
+	Code     string
+}
+
+// CodeSamples is a list of code snippets that RandomCode picks from. Some of
+// them intentionally contain tabs and very long lines.
+var CodeSamples = []CodeSample{
+	{"go", `package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: greet <name>")
+		os.Exit(1)
+	}
+
+	for i, name := range os.Args[1:] {
+		fmt.Printf("%d: Hello, %s! This line is deliberately long so that the frontend has to either wrap it or scroll it horizontally.\n", i, name)
+	}
+}`},
+	{"python", `import asyncio
+
+
+async def fetch(session, url: str) -> bytes:
+    async with session.get(url) as resp:
+        resp.raise_for_status()
+        return await resp.read()
+
+
+async def main(urls):
+    results = await asyncio.gather(*(fetch(None, u) for u in urls), return_exceptions=True)
+    for url, result in zip(urls, results):
+        print(f"{url!r}: {'error' if isinstance(result, Exception) else len(result)}")`},
+	{"rust", `use std::collections::HashMap;
+
+fn word_count(text: &str) -> HashMap<&str, usize> {
+    let mut counts = HashMap::new();
+    for word in text.split_whitespace() {
+        *counts.entry(word).or_insert(0) += 1;
+    }
+    counts
+}
+
+fn main() {
+    let counts = word_count("the quick brown fox jumps over the lazy dog the end");
+    println!("{:?}", counts.get("the"));
+}`},
+	{"javascript", `const debounce = (fn, ms = 300) => {
+  let timeout;
+  return (...args) => {
+    clearTimeout(timeout);
+    timeout = setTimeout(() => fn.apply(this, args), ms);
+  };
+};
+
+window.addEventListener("resize", debounce(() => console.log(window.innerWidth, window.innerHeight, document.documentElement.clientWidth, document.documentElement.clientHeight)));`},
+	{"c", `#include <stdio.h>
+#include <stdlib.h>
+
+int main(int argc, char *argv[]) {
+	FILE *f = fopen(argc > 1 ? argv[1] : "/dev/stdin", "r");
+	if (!f) {
+		perror("fopen");
+		return EXIT_FAILURE;
+	}
+
+	int c, lines = 0;
+	while ((c = fgetc(f)) != EOF)
+		if (c == '\n')
+			lines++;
+
+	printf("%d\n", lines);
+	fclose(f);
+	return EXIT_SUCCESS;
+}`},
+	{"sh", `#!/usr/bin/env bash
+set -euo pipefail
+
+for f in "${@:-.}"/*.png; do
+	[[ -f $f ]] || continue
+	convert "$f" -resize 50% "${f%.png}_small.png" && echo "resized $f" || echo "failed: $f" >&2
+done`},
+	{"makefile", `CFLAGS ?= -O2 -Wall
+
+all: main
+
+main: main.o util.o
+	$(CC) $(CFLAGS) -o $@ $^
+
+clean:
+	rm -f *.o main`},
+	{"json", `{
+	"name": "cchat-mock",
+	"tags": ["mock", "cchat", "testing"],
+	"nested": {"array": [1, 2, 3, {"deep": true}], "string": "a fairly long string value that keeps going and going without ever wrapping by itself"}
+}`},
+	{"", `$ go build -buildmode=plugin -o ~/.config/cchat/plugins/ ./cmd/mock/
+$ echo $?
+0`},
+}
+
+// codeIntros is a list of lines that may come before a code sample.
+var codeIntros = []string{
+	"",
+	"Can someone review this?",
+	"Why doesn't this work?",
+	"Here's what I ended up with:",
+	"Try this:",
+}
+
+// RandomCode creates a new message containing a random code sample from
+// CodeSamples.
+func RandomCode(id uint32, author Author) Message {
+	var sample = CodeSamples[rand.Intn(len(CodeSamples))]
+
+	var content = "```" + sample.Language + "\n" + sample.Code + "\n```"
+	if intro := codeIntros[rand.Intn(len(codeIntros))]; intro != "" {
+		content = intro + "\n" + content
+	}
+
+	return Message{
+		Header:  Header{id: id, time: time.Now()},
+		author:  author,
+		content: content,
+	}
+}
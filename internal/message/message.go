@@ -1,12 +1,18 @@
 package message
 
 import (
+	"fmt"
+	"math/rand"
 	"strings"
 	"time"
 
 	"github.com/diamondburned/aqs/incr"
 	"github.com/diamondburned/cchat"
+	"github.com/diamondburned/cchat-mock/internal/emoji"
+	"github.com/diamondburned/cchat-mock/internal/markdown"
+	"github.com/diamondburned/cchat-mock/segments"
 	"github.com/diamondburned/cchat/text"
+	"github.com/lucasb-eyer/go-colorful"
 
 	_ "github.com/diamondburned/aqs/data"
 )
@@ -16,6 +22,25 @@ type Message struct {
 	author  Author
 	content string
 	nonce   string
+
+	edited    time.Time
+	revisions []Revision
+
+	// emojis is the set of custom emojis that the content is rendered with.
+	emojis *emoji.Set
+	// gradient is the list of color stops to color the content with per rune.
+	gradient []colorful.Color
+	// action is true if the message is an action, such as from /me, which is
+	// rendered in italics.
+	action bool
+	// self is true if the message was sent by the current user.
+	self bool
+}
+
+// Revision is an old version of a message's content.
+type Revision struct {
+	Content string
+	Time    time.Time // when this content was written
 }
 
 var (
@@ -32,15 +57,27 @@ func NewEmpty(id uint32, author Author) Message {
 	}
 }
 
+// New creates a new message with the given Markdown content.
+func New(id uint32, author Author, content string) Message {
+	return Message{
+		Header:  Header{id: id, time: time.Now()},
+		author:  author,
+		content: content,
+	}
+}
+
+// NewRandomFromMessage edits the old message with new random content. The old
+// content is kept as a revision.
 func NewRandomFromMessage(old Message) Message {
-	return NewRandom(old.id, old.author)
+	old.Edit(randomContent(old.author), time.Now())
+	return old
 }
 
 func NewRandom(id uint32, author Author) Message {
 	return Message{
 		Header:  Header{id: id, time: time.Now()},
 		author:  author,
-		content: incr.RandomQuote(author.char),
+		content: randomContent(author),
 	}
 }
 
@@ -56,16 +93,102 @@ func Echo(sendable cchat.SendableMessage, id uint32, author Author) Message {
 	return echo
 }
 
+// NewSystem creates a new message sent by the SystemAuthor.
+func NewSystem(id uint32, content string) Message {
+	return Message{
+		Header:  Header{id: id, time: time.Now()},
+		author:  SystemAuthor,
+		content: content,
+	}
+}
+
 func Random(id uint32) Message {
 	return RandomWithAuthor(id, RandomAuthor())
 }
 
 func RandomWithAuthor(id uint32, author Author) Message {
-	return Message{
+	var msg = Message{
 		Header:  Header{id: id, time: time.Now()},
 		author:  author,
-		content: incr.RandomQuote(author.char),
+		content: randomContent(author),
 	}
+
+	// Rarely, make the whole message a gradient.
+	if rand.Intn(15) == 0 {
+		msg.gradient = segments.RandomGradient()
+	}
+
+	return msg
+}
+
+// markups is the list of Markdown delimiters that randomContent may wrap words
+// with.
+var markups = []string{"**", "*", "__", "~~", "`", "||"}
+
+// links is the list of URLs that randomContent may add.
+var links = []string{
+	"https://github.com/diamondburned/cchat",
+	"https://github.com/diamondburned/cchat-mock",
+	"https://en.wikipedia.org/wiki/Astolfo",
+	"https://example.com/some/really/long/path/that/goes/on?and=has&a=query#fragment",
+}
+
+// imageSizes is the list of requested image sizes that randomContent may use.
+var imageSizes = [][2]int{{0, 0}, {150, 150}, {225, 350}, {400, 225}}
+
+// randomContent returns a random quote from the author, sometimes decorated
+// with Markdown, emojis, links or images. If StressUnicode is true, then the
+// content is sometimes tricky text instead.
+func randomContent(author Author) string {
+	if StressUnicode && rand.Intn(2) == 0 {
+		return RandomStressContent()
+	}
+
+	var quote = incr.RandomQuote(author.char)
+
+	switch n := rand.Intn(12); {
+	case n < 3:
+		// Wrap a random word with some markup.
+		words := strings.Fields(quote)
+		if len(words) == 0 {
+			break
+		}
+
+		markup := markups[rand.Intn(len(markups))]
+		i := rand.Intn(len(words))
+		words[i] = markup + words[i] + markup
+
+		return strings.Join(words, " ")
+
+	case n < 4:
+		return "> " + quote
+
+	case n < 5:
+		return quote + " :" + emoji.RandomUnicode() + ":"
+
+	case n < 6:
+		link := links[rand.Intn(len(links))]
+		if rand.Intn(2) == 0 {
+			return quote + " " + link
+		}
+		return quote + " [source](" + link + ")"
+
+	case n < 7:
+		if author.char.ImageURL == "" {
+			break
+		}
+		if rand.Intn(2) == 0 {
+			return quote + "\n" + author.char.ImageURL
+		}
+
+		size := imageSizes[rand.Intn(len(imageSizes))]
+		return fmt.Sprintf(
+			"%s\n![%s](%s =%dx%d)",
+			quote, author.char.Name, author.char.ImageURL, size[0], size[1],
+		)
+	}
+
+	return quote
 }
 
 func (m Message) Author() cchat.Author {
@@ -81,8 +204,58 @@ func (m Message) AuthorName() string {
 	return m.author.name.Content
 }
 
+// Content returns the message content rendered from Markdown.
 func (m Message) Content() text.Rich {
-	return text.Rich{Content: m.content}
+	var rich = markdown.ParseWithEmojis(m.content, m.emojis)
+	if m.gradient != nil {
+		rich.Segments = append(
+			rich.Segments,
+			segments.NewGradientSegments(rich.Content, 0, m.gradient...)...,
+		)
+	}
+	if m.action && rich.Content != "" {
+		rich.Segments = append(
+			rich.Segments,
+			segments.NewAttributeSegment(0, len(rich.Content), text.AttributeItalics),
+		)
+	}
+
+	return rich
+}
+
+// WithEmojis returns a copy of the message that renders custom emojis from the
+// given set.
+func (m Message) WithEmojis(set *emoji.Set) Message {
+	m.emojis = set
+	return m
+}
+
+// WithAction returns a copy of the message that is rendered as an action.
+func (m Message) WithAction() Message {
+	m.action = true
+	return m
+}
+
+// WithSelf returns a copy of the message that is marked as sent by the current
+// user.
+func (m Message) WithSelf() Message {
+	m.self = true
+	return m
+}
+
+// IsSelf returns true if the message was sent by the current user.
+func (m Message) IsSelf() bool {
+	return m.self
+}
+
+// IsAction returns true if the message is an action.
+func (m Message) IsAction() bool {
+	return m.action
+}
+
+// RawContent returns the message content as Markdown.
+func (m Message) RawContent() string {
+	return m.content
 }
 
 func (m Message) Nonce() string {
@@ -98,3 +271,37 @@ func (m Message) Mentioned() bool {
 func (m *Message) SetContent(content string) {
 	m.content = content
 }
+
+// Edit replaces the message content and marks the message as edited at the
+// given time. The old content is appended into the revision history.
+func (m *Message) Edit(content string, t time.Time) {
+	var since = m.time
+	if m.IsEdited() {
+		since = m.edited
+	}
+
+	// Force a copy, since other copies of this message may share the backing
+	// array.
+	m.revisions = append(
+		m.revisions[:len(m.revisions):len(m.revisions)],
+		Revision{Content: m.content, Time: since},
+	)
+	m.content = content
+	m.edited = t
+}
+
+// IsEdited returns true if the message has been edited at least once.
+func (m Message) IsEdited() bool {
+	return !m.edited.IsZero()
+}
+
+// Edited returns the time of the last edit, or a zero time if the message was
+// never edited.
+func (m Message) Edited() time.Time {
+	return m.edited
+}
+
+// Revisions returns the previous contents of the message, oldest first.
+func (m Message) Revisions() []Revision {
+	return m.revisions
+}
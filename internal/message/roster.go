@@ -0,0 +1,67 @@
+package message
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Member is a member of a server's roster.
+type Member struct {
+	Author
+	// LastSpoke is the time the member last sent a message. It is zero if the
+	// member has never spoken.
+	LastSpoke time.Time
+}
+
+// Roster is the list of members in a server. It is safe for concurrent use.
+type Roster struct {
+	mutex   sync.Mutex
+	members map[string]Member
+}
+
+// NewRoster creates a new empty roster.
+func NewRoster() *Roster {
+	return &Roster{members: map[string]Member{}}
+}
+
+// RandomRoster creates a new roster with up to n random members who have never
+// spoken.
+func RandomRoster(n int) *Roster {
+	var roster = NewRoster()
+	for i := 0; i < n; i++ {
+		author := RandomAuthor()
+		roster.members[author.ID()] = Member{Author: author}
+	}
+	return roster
+}
+
+// Spoke adds the author into the roster if needed and updates when they last
+// spoke.
+func (r *Roster) Spoke(author Author, t time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if m, ok := r.members[author.ID()]; ok && m.LastSpoke.After(t) {
+		return
+	}
+
+	r.members[author.ID()] = Member{Author: author, LastSpoke: t}
+}
+
+// Members returns all members sorted by ID.
+func (r *Roster) Members() []Member {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var members = make([]Member, 0, len(r.members))
+	for _, m := range r.members {
+		members = append(members, m)
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].ID() < members[j].ID()
+	})
+
+	return members
+}
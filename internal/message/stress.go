@@ -0,0 +1,154 @@
+package message
+
+import (
+	"math/rand"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/diamondburned/cchat-mock/segments"
+	"github.com/diamondburned/cchat/text"
+)
+
+// StressUnicode enables generating names and messages with adversarial Unicode
+// text, such as combining characters, ZWJ sequences, bidirectional text and
+// zero-width characters. It is meant to stress the frontend's text bounds
+// math.
+var StressUnicode = false
+
+// stressNames is a list of short pieces of tricky text.
+var stressNames = []string{
+	"Z\u0324\u0354\u0367\u0311\u0313a\u0308\u0356\u032d\u0308\u0307l\u036e\u0312\u036b", // combining marks
+	"Re\u0301sume\u0301 Cafe\u0301",                                      // decomposed accents
+	"\U0001F468\u200D\U0001F469\u200D\U0001F467\u200D\U0001F466 Family",  // ZWJ sequence
+	"\U0001F469\U0001F3FD\u200D\U0001F4BB Coder",                         // skin tone and ZWJ
+	"\U0001F3F3\uFE0F\u200D\U0001F308\U0001F3F3\uFE0F\u200D\u26A7\uFE0F", // flag ZWJ sequences
+	"\U0001F1EF\U0001F1F5\U0001F1FA\U0001F1F8\U0001F1E7\U0001F1F7",       // regional indicators
+	"שלום עולם",                                  // RTL
+	"مرحبا بالعالم",                              // RTL with joining
+	"abc שלום 123 عربي def",                      // bidi mix
+	"\u202Eevil override\u202C",                  // bidi override
+	"\u2067isolated\u2069 text",                  // bidi isolate
+	"日本語のテキスト",                                   // CJK
+	"中文字符 and 한국어",                               // CJK mix
+	"ＦＵＬＬＷＩＤＴＨ",                                  // fullwidth
+	"zero\u200Bwidth\u200Cnon\u200Djoiner\uFEFF", // zero-width characters
+	"\u263A\uFE0E vs \u263A\uFE0F",               // variation selectors
+	"\U0001D509\U0001D52F\U0001D51E\U0001D528\U0001D531\U0001D532\U0001D52F", // astral plane letters
+	"\u0E01\u0E48\u0E01\u0E48\u0E01\u0E48",                                   // stacked Thai marks
+}
+
+// stressMarkups is the list of markups that stress content wraps tricky text
+// with.
+var stressMarkups = []string{"**", "~~", "||", "__"}
+
+// StressName returns a random tricky name.
+func StressName() string {
+	return stressNames[rand.Intn(len(stressNames))]
+}
+
+// RandomStressAuthor returns a random author with a tricky name. The name is
+// colored from a random rune in the middle, so the segment starts on a tricky
+// boundary.
+func RandomStressAuthor() Author {
+	var author = randomCharAuthor()
+
+	var name = StressName()
+	if rand.Intn(4) == 0 {
+		name = strings.Repeat(name, 8) // absurdly long
+	}
+
+	var i = randomBoundary(name)
+	if i == len(name) {
+		i = 0 // don't make an empty segment
+	}
+
+	var b segments.Builder
+	b.Write(name[:i])
+	b.Colorful(name[i:], Readable(author.char.NameColor()))
+
+	author.name = b.Rich()
+	return author
+}
+
+// RandomStressContent returns random Markdown content made of tricky text, with
+// markup starting and ending on tricky boundaries such as in between a base
+// character and its combining marks.
+func RandomStressContent() string {
+	switch rand.Intn(10) {
+	case 0:
+		// A huge message.
+		var b strings.Builder
+		for b.Len() < 64*1024 {
+			b.WriteString(StressName())
+			b.WriteString(" ")
+			if rand.Intn(10) == 0 {
+				b.WriteString("\n")
+			}
+		}
+		return b.String()
+
+	case 1:
+		// A very long unbroken word.
+		return strings.Repeat("Supercalifragilisticexpialidocious", 60)
+	}
+
+	var pieces = make([]string, rand.Intn(4)+2)
+	for i := range pieces {
+		pieces[i] = wrapStress(StressName())
+	}
+
+	return strings.Join(pieces, " ")
+}
+
+// wrapStress wraps a random range of runes inside the given text with markup.
+func wrapStress(s string) string {
+	i, j := randomBoundary(s), randomBoundary(s)
+	if i > j {
+		i, j = j, i
+	}
+
+	// Markup can't start or end with spaces, and can't be empty.
+	var inner = s[i:j]
+	if inner == "" || strings.TrimSpace(inner) != inner {
+		return s
+	}
+
+	var markup = stressMarkups[rand.Intn(len(stressMarkups))]
+	return s[:i] + markup + inner + markup + s[j:]
+}
+
+// randomBoundary returns a random byte offset in s that doesn't split a UTF-8
+// sequence. It may still split grapheme clusters, which is intended.
+func randomBoundary(s string) int {
+	var n = rand.Intn(utf8.RuneCountInString(s) + 1)
+
+	for i := range s {
+		if n == 0 {
+			return i
+		}
+		n--
+	}
+
+	return len(s)
+}
+
+// StressRich returns the given tricky text as rich text with a dimmed segment
+// starting from its middle rune, which is likely a tricky boundary.
+func StressRich(s string) text.Rich {
+	var i = len(s)
+	var n = utf8.RuneCountInString(s) / 2
+
+	for j := range s {
+		if n == 0 {
+			i = j
+			break
+		}
+		n--
+	}
+
+	var b segments.Builder
+	b.Write(s[:i])
+	b.Attributed(s[i:], text.AttributeDimmed)
+
+	return b.Rich()
+}
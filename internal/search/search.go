@@ -0,0 +1,133 @@
+// Package search implements a simple query syntax for searching messages.
+package search
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/cchat-mock/internal/message"
+	"github.com/pkg/errors"
+)
+
+// MaxResults is the maximum number of results that a search should yield.
+const MaxResults = 50
+
+// Query is a parsed search query. All non-zero fields must match for a message
+// to match the query.
+type Query struct {
+	// Terms are lower-cased substrings that must all be in the content.
+	Terms []string
+	// From is a lower-cased substring of the author's name.
+	From string
+	// In is a lower-cased substring of the channel's name.
+	In string
+
+	Before time.Time
+	After  time.Time
+}
+
+// Parse parses the given words into a query. Words may be plain substrings or
+// one of the following filters:
+//
+//	from:name      author name contains name
+//	in:#channel    channel name contains channel
+//	before:time    sent before time
+//	after:time     sent after time
+//
+// Time can be a duration ago such as "5m", a clock time such as "15:04", a date
+// such as "2006-01-02" or a RFC3339 timestamp.
+func Parse(words []string) (Query, error) {
+	var q Query
+
+	for _, word := range words {
+		var key, value string
+		if parts := strings.SplitN(word, ":", 2); len(parts) == 2 {
+			key, value = parts[0], parts[1]
+		}
+
+		var err error
+
+		switch key {
+		case "from":
+			q.From = strings.ToLower(value)
+		case "in":
+			q.In = strings.ToLower(strings.TrimPrefix(value, "#"))
+		case "before":
+			q.Before, err = parseTime(value)
+		case "after":
+			q.After, err = parseTime(value)
+		default:
+			if word != "" {
+				q.Terms = append(q.Terms, strings.ToLower(word))
+			}
+		}
+
+		if err != nil {
+			return q, errors.Wrapf(err, "Invalid %s", key)
+		}
+	}
+
+	return q, nil
+}
+
+// parseTime parses the time value of a before or after filter.
+func parseTime(value string) (time.Time, error) {
+	var now = time.Now()
+
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+
+	if t, err := time.ParseInLocation("15:04", value, time.Local); err == nil {
+		y, m, d := now.Date()
+		return time.Date(y, m, d, t.Hour(), t.Minute(), 0, 0, time.Local), nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+// MatchChannel returns true if the channel with the given name should be
+// searched.
+func (q Query) MatchChannel(name string) bool {
+	name = strings.ToLower(strings.TrimPrefix(name, "#"))
+	return q.In == "" || strings.Contains(name, q.In)
+}
+
+// Match returns true if the message matches the query.
+func (q Query) Match(msg message.Message) bool {
+	if q.From != "" && !strings.Contains(strings.ToLower(msg.AuthorName()), q.From) {
+		return false
+	}
+
+	if !q.Before.IsZero() && !msg.Time().Before(q.Before) {
+		return false
+	}
+
+	if !q.After.IsZero() && !msg.Time().After(q.After) {
+		return false
+	}
+
+	var content = strings.ToLower(msg.Content().String())
+
+	for _, term := range q.Terms {
+		if !strings.Contains(content, term) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// WriteResult writes a single search result as a line into w.
+func WriteResult(w io.Writer, channel string, msg message.Message) {
+	fmt.Fprintf(
+		w, "%s %s [%s] %s: %s\n",
+		channel, msg.ID(), msg.Time().Format(time.Kitchen), msg.AuthorName(), msg.Content(),
+	)
+}
@@ -3,11 +3,14 @@ package server
 import (
 	"math/rand"
 	"strconv"
+	"sync"
 
 	"github.com/Pallinder/go-randomdata"
 	"github.com/diamondburned/cchat"
 	"github.com/diamondburned/cchat-mock/internal/channel"
+	"github.com/diamondburned/cchat-mock/internal/emoji"
 	"github.com/diamondburned/cchat-mock/internal/internet"
+	"github.com/diamondburned/cchat-mock/internal/message"
 	"github.com/diamondburned/cchat-mock/internal/shared"
 	"github.com/diamondburned/cchat/text"
 	"github.com/diamondburned/cchat/utils/empty"
@@ -18,7 +21,14 @@ type Server struct {
 	state    *shared.State
 	id       uint32
 	name     string
+	emojis   *emoji.Set
+	roster   *message.Roster
 	children ChannelList
+
+	// containers are the containers that the channels were listed into, which
+	// are updated when a channel changes.
+	containerMutex sync.Mutex
+	containers     []cchat.ServersContainer
 }
 
 var _ cchat.Server = (*Server)(nil)
@@ -41,12 +51,20 @@ func AsCChatServers(servers []*Server) []cchat.Server {
 }
 
 func New(state *shared.State) *Server {
-	return &Server{
-		state:    state,
-		id:       state.NextID(),
-		name:     randomdata.Noun(),
-		children: RandomChannels(state, rand.Intn(12)+5),
+	sv := &Server{
+		state:  state,
+		id:     state.NextID(),
+		name:   randomdata.Noun(),
+		emojis: emoji.RandomSet(rand.Intn(10) + 5),
+		roster: message.RandomRoster(rand.Intn(20) + 10),
+	}
+	sv.children = RandomChannels(state, sv, rand.Intn(12)+5)
+
+	if message.StressUnicode && rand.Intn(3) == 0 {
+		sv.name = message.StressName()
 	}
+
+	return sv
 }
 
 func (sv *Server) ID() string {
@@ -54,17 +72,95 @@ func (sv *Server) ID() string {
 }
 
 func (sv *Server) Name() text.Rich {
+	if message.StressUnicode {
+		return message.StressRich(sv.name)
+	}
 	return text.Plain(sv.name)
 }
 
+// Emojis returns the server's custom emojis.
+func (sv *Server) Emojis() *emoji.Set {
+	return sv.emojis
+}
+
+// Roster returns the server's members.
+func (sv *Server) Roster() *message.Roster {
+	return sv.roster
+}
+
 func (sv *Server) AsLister() cchat.Lister {
-	return sv.children
+	return sv
+}
+
+// Servers lists the channels into the container and keeps the container
+// updated on channel changes.
+func (sv *Server) Servers(container cchat.ServersContainer) error {
+	if err := sv.state.Check(); err != nil {
+		return err
+	}
+
+	if err := sv.children.Servers(container); err != nil {
+		return err
+	}
+
+	sv.containerMutex.Lock()
+	if !shared.HasContainer(sv.containers, container) {
+		sv.containers = append(sv.containers, container)
+	}
+	sv.containerMutex.Unlock()
+
+	return nil
+}
+
+// Close forgets all containers that the channels were listed into.
+func (sv *Server) Close() {
+	sv.containerMutex.Lock()
+	sv.containers = nil
+	sv.containerMutex.Unlock()
+}
+
+// UpdateChannel updates the channel in all containers that the channels were
+// listed into.
+func (sv *Server) UpdateChannel(ch *channel.Channel) {
+	sv.containerMutex.Lock()
+	defer sv.containerMutex.Unlock()
+
+	for _, container := range sv.containers {
+		container.UpdateServer(channelUpdate{ch})
+	}
+}
+
+// channelUpdate replaces a channel with its new state.
+type channelUpdate struct {
+	*channel.Channel
+}
+
+var _ cchat.ServerUpdate = (*channelUpdate)(nil)
+
+func (update channelUpdate) PreviousID() (cchat.ID, bool) {
+	return update.ID(), true
+}
+
+// Channels returns the list of channels in the server.
+func (sv *Server) Channels() []*channel.Channel {
+	var channels = make([]*channel.Channel, 0, len(sv.children))
+	for _, ch := range sv.children {
+		if ch, ok := ch.(*channel.Channel); ok {
+			channels = append(channels, ch)
+		}
+	}
+	return channels
 }
 
 type ChannelList []cchat.Server
 
-func RandomChannels(state *shared.State, n int) ChannelList {
-	return channel.AsCChatServers(channel.NewChannels(state, n))
+// RandomChannels creates n random channels, the first of which is a code
+// channel.
+func RandomChannels(state *shared.State, parent channel.Parent, n int) ChannelList {
+	var channels = []*channel.Channel{channel.NewCodeChannel(state, parent)}
+	channels = append(channels, channel.NewChannels(state, parent, n-1)...)
+
+	return channel.AsCChatServers(channels)
 }
 
 func (chl ChannelList) Servers(container cchat.ServersContainer) error {
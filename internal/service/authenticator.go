@@ -26,8 +26,9 @@ func (Authenticator) AuthenticateForm() []cchat.AuthenticateEntry {
 			Name: "Username",
 		},
 		{
-			Name:   "Password (ignored)",
-			Secret: true,
+			Name:        "Password",
+			Description: "Only checked if a user database is configured.",
+			Secret:      true,
 		},
 		{
 			Name:      "Paragraph (ignored)",
@@ -36,14 +37,29 @@ func (Authenticator) AuthenticateForm() []cchat.AuthenticateEntry {
 	}
 }
 
-func (Authenticator) Authenticate(form []string) (cchat.Session, cchat.AuthenticateError) {
+func (auth Authenticator) Authenticate(form []string) (cchat.Session, cchat.AuthenticateError) {
+	var entries = auth.AuthenticateForm()
+
+	// The password is only required with a user database.
+	var required = form[:1]
+	if UserDatabase != "" {
+		required = form[:2]
+	}
+
+	if err := checkEmpty(required, entries); err != nil {
+		return nil, err
+	}
+
 	// SLOW IO TIME.
-	err := internet.SimulateAustralian()
-	if err == nil {
-		return session.New(form[0], ""), nil
+	if err := internet.SimulateAustralian(); err != nil {
+		return nil, cchat.WrapAuthenticateError(errors.Wrap(err, "Authentication failed"))
 	}
 
-	return nil, cchat.WrapAuthenticateError(errors.Wrap(err, "Authentication failed"))
+	if err := checkCredentials(form, entries); err != nil {
+		return nil, err
+	}
+
+	return session.New(form[0], ""), nil
 }
 
 type FastAuthenticator struct{}
@@ -66,6 +82,10 @@ func (FastAuthenticator) AuthenticateForm() []cchat.AuthenticateEntry {
 	}
 }
 
-func (FastAuthenticator) Authenticate(form []string) (cchat.Session, cchat.AuthenticateError) {
+func (auth FastAuthenticator) Authenticate(form []string) (cchat.Session, cchat.AuthenticateError) {
+	if err := checkEmpty(form, auth.AuthenticateForm()); err != nil {
+		return nil, err
+	}
+
 	return session.New(form[0], ""), nil
 }
@@ -3,9 +3,14 @@ package service
 import (
 	"encoding/json"
 	"strconv"
+	"time"
 
 	"github.com/diamondburned/cchat"
+	"github.com/diamondburned/cchat-mock/internal/channel"
 	"github.com/diamondburned/cchat-mock/internal/internet"
+	"github.com/diamondburned/cchat-mock/internal/message"
+	"github.com/diamondburned/cchat-mock/internal/shared"
+	"github.com/diamondburned/cchat-mock/segments"
 )
 
 type Configurator struct{}
@@ -16,6 +21,17 @@ func (Configurator) Configuration() (map[string]string, error) {
 		"internet.CanFail":    strconv.FormatBool(internet.CanFail),
 		"internet.MinLatency": strconv.Itoa(internet.MinLatency),
 		"internet.MaxLatency": strconv.Itoa(internet.MaxLatency),
+		// refer to message/stress.go
+		"message.StressUnicode": strconv.FormatBool(message.StressUnicode),
+		// refer to channel/validate.go
+		"channel.ValidateSegments": strconv.FormatBool(channel.ValidateSegments),
+		// refer to message/author.go; this one is not JSON
+		"message.Background": message.Background,
+		// refer to service/credentials.go; this one is not JSON either
+		"service.UserDatabase": UserDatabase,
+		// refer to shared/token.go; the lifetime is a Go duration string
+		"shared.SessionLifetime":  shared.SessionLifetime.String(),
+		"shared.InvalidateChance": strconv.FormatFloat(shared.InvalidateChance, 'f', -1, 64),
 	}, nil
 }
 
@@ -26,11 +42,18 @@ func (Configurator) SetConfiguration(config map[string]string) error {
 		unmarshalConfig(config, "internet.CanFail", &internet.CanFail),
 		unmarshalConfig(config, "internet.MinLatency", &internet.MinLatency),
 		unmarshalConfig(config, "internet.MaxLatency", &internet.MaxLatency),
+		unmarshalConfig(config, "message.StressUnicode", &message.StressUnicode),
+		unmarshalConfig(config, "channel.ValidateSegments", &channel.ValidateSegments),
+		setBackground(config, "message.Background"),
+		unmarshalConfig(config, "shared.InvalidateChance", &shared.InvalidateChance),
+		setDuration(config, "shared.SessionLifetime", &shared.SessionLifetime),
 	} {
 		if err != nil {
 			return err
 		}
 	}
+
+	UserDatabase = config["service.UserDatabase"]
 	return nil
 }
 
@@ -43,3 +66,32 @@ func unmarshalConfig(config map[string]string, key string, value interface{}) er
 	}
 	return nil
 }
+
+// setBackground sets message.Background, which is a plain string instead of
+// JSON, after validating it.
+func setBackground(config map[string]string, key string) error {
+	bg := config[key]
+	if _, err := segments.ParseBackground(bg); err != nil {
+		return &cchat.ErrInvalidConfigAtField{
+			Key: key,
+			Err: err,
+		}
+	}
+
+	message.Background = bg
+	return nil
+}
+
+// setDuration sets the duration from a Go duration string such as "24h".
+func setDuration(config map[string]string, key string, value *time.Duration) error {
+	d, err := time.ParseDuration(config[key])
+	if err != nil {
+		return &cchat.ErrInvalidConfigAtField{
+			Key: key,
+			Err: err,
+		}
+	}
+
+	*value = d
+	return nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"github.com/diamondburned/cchat"
+	"github.com/diamondburned/cchat-mock/internal/internet"
+	"github.com/diamondburned/cchat-mock/internal/session"
+	"github.com/diamondburned/cchat-mock/internal/userdb"
+	"github.com/diamondburned/cchat/text"
+	"github.com/pkg/errors"
+)
+
+// UserDatabase is the path to the local user database file. Credentials are
+// not checked if it is empty.
+var UserDatabase = ""
+
+// FieldError is an authentication error caused by a specific form field.
+// The field index is only used internally; frontends see it as a regular
+// cchat.AuthenticateError whose message names the field.
+type FieldError struct {
+	Field int // index into the AuthenticateForm entries
+	Name  string
+	Err   error
+}
+
+var _ cchat.AuthenticateError = (*FieldError)(nil)
+
+func (err *FieldError) Error() string {
+	return err.Name + ": " + err.Err.Error()
+}
+
+func (err *FieldError) Unwrap() error {
+	return err.Err
+}
+
+// NextStage returns nil, as the same form should be retried.
+func (err *FieldError) NextStage() []cchat.Authenticator {
+	return nil
+}
+
+// ErrEmptyField is returned when a required form field is empty.
+var ErrEmptyField = errors.New("Required.")
+
+// checkEmpty returns a FieldError for the first empty field.
+func checkEmpty(form []string, entries []cchat.AuthenticateEntry) cchat.AuthenticateError {
+	for i, value := range form {
+		if value == "" {
+			return &FieldError{i, entries[i].Name, ErrEmptyField}
+		}
+	}
+	return nil
+}
+
+// checkCredentials checks the username and password in the first two fields
+// of the form against the user database, if any.
+func checkCredentials(form []string, entries []cchat.AuthenticateEntry) cchat.AuthenticateError {
+	if UserDatabase == "" {
+		return nil
+	}
+
+	switch err := userdb.Authenticate(UserDatabase, form[0], form[1]); err {
+	case nil:
+		return nil
+	case userdb.ErrUnknownUser, userdb.ErrLocked:
+		return &FieldError{0, entries[0].Name, err}
+	case userdb.ErrWrongPassword:
+		return &FieldError{1, entries[1].Name, err}
+	default:
+		return cchat.WrapAuthenticateError(err)
+	}
+}
+
+// RegisterAuthenticator registers a new user into the user database.
+type RegisterAuthenticator struct{}
+
+var _ cchat.Authenticator = (*RegisterAuthenticator)(nil)
+
+func (RegisterAuthenticator) Name() text.Rich {
+	return text.Plain("Register")
+}
+
+func (RegisterAuthenticator) Description() text.Rich {
+	return text.Plain("Create a new user in the local user database.")
+}
+
+func (RegisterAuthenticator) AuthenticateForm() []cchat.AuthenticateEntry {
+	return []cchat.AuthenticateEntry{
+		{
+			Name: "Username",
+		},
+		{
+			Name:   "Password",
+			Secret: true,
+		},
+		{
+			Name:   "Confirm Password",
+			Secret: true,
+		},
+	}
+}
+
+func (auth RegisterAuthenticator) Authenticate(form []string) (cchat.Session, cchat.AuthenticateError) {
+	if UserDatabase == "" {
+		return nil, cchat.WrapAuthenticateError(errors.New("No user database is configured."))
+	}
+
+	var entries = auth.AuthenticateForm()
+
+	if err := checkEmpty(form, entries); err != nil {
+		return nil, err
+	}
+
+	if form[1] != form[2] {
+		return nil, &FieldError{2, entries[2].Name, errors.New("Passwords don't match.")}
+	}
+
+	if err := internet.SimulateAustralian(); err != nil {
+		return nil, cchat.WrapAuthenticateError(errors.Wrap(err, "Registration failed"))
+	}
+
+	switch err := userdb.Register(UserDatabase, form[0], form[1]); err {
+	case nil:
+		return session.New(form[0], ""), nil
+	case userdb.ErrUserExists:
+		return nil, &FieldError{0, entries[0].Name, err}
+	default:
+		return nil, cchat.WrapAuthenticateError(err)
+	}
+}
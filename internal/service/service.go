@@ -37,6 +37,8 @@ func (s Service) Authenticate() []cchat.Authenticator {
 	return []cchat.Authenticator{
 		Authenticator{},
 		FastAuthenticator{},
+		TwoFactorAuthenticator{},
+		RegisterAuthenticator{},
 	}
 }
 
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/diamondburned/cchat"
+	"github.com/diamondburned/cchat-mock/internal/internet"
+	"github.com/diamondburned/cchat-mock/internal/session"
+	"github.com/diamondburned/cchat-mock/internal/totp"
+	"github.com/diamondburned/cchat/text"
+	"github.com/pkg/errors"
+)
+
+// EmailCodePath is the file that emailed login codes are written to.
+var EmailCodePath = filepath.Join(os.TempDir(), "cchat-mock-email.txt")
+
+// EmailCodeExpiry is how long an emailed login code is valid for.
+const EmailCodeExpiry = 10 * time.Minute
+
+// ErrInvalidCode is returned when a second-stage code is wrong.
+var ErrInvalidCode = errors.New("Invalid code.")
+
+// stageError is returned when authentication requires another stage.
+type stageError struct {
+	stages []cchat.Authenticator
+}
+
+var _ cchat.AuthenticateError = (*stageError)(nil)
+
+func (err stageError) Error() string {
+	return "Two-factor authentication required."
+}
+
+func (err stageError) NextStage() []cchat.Authenticator {
+	return err.stages
+}
+
+// TwoFactorAuthenticator checks the username and password, then requires a
+// code from an authenticator app or an email as the second stage.
+type TwoFactorAuthenticator struct{}
+
+var _ cchat.Authenticator = (*TwoFactorAuthenticator)(nil)
+
+func (TwoFactorAuthenticator) Name() text.Rich {
+	return text.Plain("Two-Factor Authentication")
+}
+
+func (TwoFactorAuthenticator) Description() text.Rich {
+	return text.Plain("Requires a code from an authenticator app or an email.")
+}
+
+func (TwoFactorAuthenticator) AuthenticateForm() []cchat.AuthenticateEntry {
+	return []cchat.AuthenticateEntry{
+		{
+			Name: "Username",
+		},
+		{
+			Name:   "Password",
+			Secret: true,
+		},
+	}
+}
+
+func (auth TwoFactorAuthenticator) Authenticate(form []string) (cchat.Session, cchat.AuthenticateError) {
+	var entries = auth.AuthenticateForm()
+
+	if err := checkEmpty(form, entries); err != nil {
+		return nil, err
+	}
+
+	if err := internet.SimulateAustralian(); err != nil {
+		return nil, cchat.WrapAuthenticateError(errors.Wrap(err, "Authentication failed"))
+	}
+
+	if err := checkCredentials(form, entries); err != nil {
+		return nil, err
+	}
+
+	var stages = []cchat.Authenticator{newTOTPAuthenticator(form[0])}
+
+	// Skip the email stage if the code can't be written.
+	if email, err := newEmailAuthenticator(form[0]); err == nil {
+		stages = append(stages, email)
+	} else {
+		log.Println("Failed to email login code:", err)
+	}
+
+	return nil, stageError{stages}
+}
+
+// totpAuthenticator is the second stage that checks a code computed from a
+// shown secret.
+type totpAuthenticator struct {
+	username string
+	secret   string
+}
+
+func newTOTPAuthenticator(username string) totpAuthenticator {
+	return totpAuthenticator{username, totp.NewSecret()}
+}
+
+func (auth totpAuthenticator) Name() text.Rich {
+	return text.Plain("Authenticator App")
+}
+
+func (auth totpAuthenticator) Description() text.Rich {
+	return text.Plain(fmt.Sprintf(
+		"Enter the code for the secret %s (%s).",
+		auth.secret, totp.URI("cchat-mock", auth.username, auth.secret),
+	))
+}
+
+func (auth totpAuthenticator) AuthenticateForm() []cchat.AuthenticateEntry {
+	return []cchat.AuthenticateEntry{
+		{
+			Name:        "Code",
+			Placeholder: "123456",
+		},
+	}
+}
+
+func (auth totpAuthenticator) Authenticate(form []string) (cchat.Session, cchat.AuthenticateError) {
+	if err := internet.SimulateAustralian(); err != nil {
+		return nil, cchat.WrapAuthenticateError(errors.Wrap(err, "Authentication failed"))
+	}
+
+	if !totp.Validate(auth.secret, form[0], time.Now()) {
+		return nil, cchat.WrapAuthenticateError(ErrInvalidCode)
+	}
+
+	return session.New(auth.username, ""), nil
+}
+
+// emailAuthenticator is the second stage that checks a code "emailed" into
+// EmailCodePath.
+type emailAuthenticator struct {
+	username string
+	code     string
+	expires  time.Time
+}
+
+// newEmailAuthenticator generates a code and writes it into EmailCodePath.
+func newEmailAuthenticator(username string) (emailAuthenticator, error) {
+	var auth = emailAuthenticator{
+		username: username,
+		code:     fmt.Sprintf("%06d", rand.Intn(1000000)),
+		expires:  time.Now().Add(EmailCodeExpiry),
+	}
+
+	var email = fmt.Sprintf(
+		"To: %s\nSubject: Your cchat-mock login code\n\nYour login code is %s. It expires at %s.\n",
+		username, auth.code, auth.expires.Format(time.Kitchen),
+	)
+
+	if err := ioutil.WriteFile(EmailCodePath, []byte(email), 0600); err != nil {
+		return auth, errors.Wrap(err, "Failed to write email")
+	}
+
+	return auth, nil
+}
+
+func (auth emailAuthenticator) Name() text.Rich {
+	return text.Plain("Email")
+}
+
+func (auth emailAuthenticator) Description() text.Rich {
+	return text.Plain("Enter the code emailed to " + EmailCodePath + ".")
+}
+
+func (auth emailAuthenticator) AuthenticateForm() []cchat.AuthenticateEntry {
+	return []cchat.AuthenticateEntry{
+		{
+			Name:        "Code",
+			Placeholder: "123456",
+		},
+	}
+}
+
+func (auth emailAuthenticator) Authenticate(form []string) (cchat.Session, cchat.AuthenticateError) {
+	if err := internet.SimulateAustralian(); err != nil {
+		return nil, cchat.WrapAuthenticateError(errors.Wrap(err, "Authentication failed"))
+	}
+
+	if time.Now().After(auth.expires) {
+		return nil, cchat.WrapAuthenticateError(errors.New("Code expired."))
+	}
+
+	if form[0] != auth.code {
+		return nil, cchat.WrapAuthenticateError(ErrInvalidCode)
+	}
+
+	return session.New(auth.username, ""), nil
+}
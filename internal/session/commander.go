@@ -1,114 +1,230 @@
 package session
 
 import (
+	"bytes"
+	"context"
 	"fmt"
-	"strconv"
+	"io"
 	"strings"
+	"time"
 
 	"github.com/Pallinder/go-randomdata"
 	"github.com/diamondburned/cchat"
+	"github.com/diamondburned/cchat-mock/internal/command"
 	"github.com/diamondburned/cchat-mock/internal/internet"
-	"github.com/diamondburned/cchat/text"
+	"github.com/diamondburned/cchat-mock/internal/search"
+	"github.com/diamondburned/cchat-mock/internal/server"
+	"github.com/diamondburned/cchat-mock/internal/shared"
+	"github.com/diamondburned/cchat-mock/stream"
 	"github.com/pkg/errors"
 )
 
-type Commander struct{}
-
-func (c *Commander) Run(cmds []string) ([]byte, error) {
-	switch cmd := arg(cmds, 0); cmd {
-	case "ls":
-		return []byte("Commands: ls, random"), nil
-
-	case "random":
-		// callback used to generate stuff and stream into readcloser
-		var generator func() string
-		// number of times to generate the word
-		var times = 1
-
-		switch arg(cmds, 1) {
-		case "paragraph":
-			generator = randomdata.Paragraph
-		case "noun":
-			generator = randomdata.Noun
-		case "silly_name":
-			generator = randomdata.SillyName
-		default:
-			return nil, errors.New("Usage: random <paragraph|noun|silly_name> [repeat]")
-		}
+// RunTimeout is how long Run waits for a command before returning its output
+// so far. Streaming commands such as tail only stop on timeout.
+var RunTimeout = 10 * time.Second
 
-		if n := arg(cmds, 2); n != "" {
-			i, err := strconv.Atoi(n)
-			if err != nil {
-				return nil, errors.Wrap(err, "Failed to parse repeat number")
-			}
-			times = i
-		}
-
-		var err error
+type Commander struct {
+	session  *Session
+	registry *command.Registry
+}
 
-		for i := 0; i < times; i++ {
-			// Yes, we're simulating this even in something as trivial as a
-			// command prompt.
-			if err = internet.SimulateAustralian(); err == nil {
-				return []byte(generator()), nil
-			}
-		}
+var (
+	_ cchat.Commander = (*Commander)(nil)
+	_ stream.Streamer = (*Commander)(nil)
+)
 
-		return nil, err
+// NewCommander creates a new commander with all session commands registered.
+func NewCommander(s *Session) *Commander {
+	var c = &Commander{session: s}
+	c.registry = command.NewRegistry(c.commands()...)
+	return c
+}
 
-	default:
-		return nil, fmt.Errorf("Unknown command: %q", cmd)
+func (c *Commander) commands() []*command.Command {
+	var commands = []*command.Command{
+		{
+			Name: "ls",
+			Help: "List all commands",
+			Run:  c.ls,
+		},
+		{
+			Name: "random",
+			Help: "Generate random text count times, one at a time",
+			Args: []command.Arg{
+				{
+					Name:    "kind",
+					Kind:    command.Enum,
+					Choices: []string{"paragraph", "noun", "silly_name"},
+				},
+				{
+					Name:     "count",
+					Kind:     command.Int,
+					Optional: true,
+					Default:  "1",
+				},
+			},
+			Run: c.random,
+		},
+		{
+			Name: "search",
+			Help: "Search messages in all servers, printing results as they are found",
+			Args: []command.Arg{{
+				Name:     "query",
+				Kind:     command.Rest,
+				Optional: true,
+				Choices:  []string{"from:", "in:#", "before:", "after:"},
+			}},
+			Run: c.search,
+		},
 	}
+
+	commands = append(commands, c.streamCommands()...)
+	commands = append(commands, c.dumpCommands()...)
+	return append(commands, c.controlCommands()...)
 }
 
-func (s *Commander) AsCompleter() cchat.Completer { return s }
+// Run runs the command and returns its output once it finishes or after
+// RunTimeout, whichever is sooner. Output cut off by the timeout ends with a
+// line saying so. Frontends should use RunContext from stream.Streamer to show
+// output as it is written instead.
+func (c *Commander) Run(words []string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), RunTimeout)
+	defer cancel()
 
-func (s *Commander) Complete(words []string, i int64) []cchat.CompletionEntry {
-	switch {
-	case strings.HasPrefix("ls", words[i]):
-		return newCompEntries("ls")
+	var buf bytes.Buffer
 
-	case strings.HasPrefix("random", words[i]):
-		return newCompEntries(
-			"random paragraph",
-			"random noun",
-			"random silly_name",
-		)
+	switch err := c.RunContext(ctx, words, &buf); err {
+	case nil:
+	case context.DeadlineExceeded:
+		fmt.Fprintf(&buf, "\n(timed out after %v)", RunTimeout)
+	default:
+		return nil, err
+	}
 
-	case lookbackCheck(words, i, "random", "paragraph"):
-		return newCompEntries("paragraph")
+	return buf.Bytes(), nil
+}
 
-	case lookbackCheck(words, i, "random", "noun"):
-		return newCompEntries("noun")
+// RunContext runs the command and writes its output into w as it goes until
+// the command finishes, the context is canceled or the session is
+// disconnected.
+func (c *Commander) RunContext(ctx context.Context, words []string, w io.Writer) error {
+	if err := c.session.State.Check(); err != nil {
+		return err
+	}
 
-	case lookbackCheck(words, i, "random", "silly_name"):
-		return newCompEntries("silly_name")
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	default:
-		return nil
+	err := c.session.State.Go(func(sessionCtx context.Context) {
+		select {
+		case <-sessionCtx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	})
+	if err != nil {
+		return err
 	}
+
+	err = c.registry.RunContext(ctx, words, w)
+	if err != nil && c.session.State.Context().Err() != nil {
+		return shared.ErrDisconnected
+	}
+
+	return err
 }
 
-// completion will only override `this'.
-func lookbackCheck(words []string, i int64, prev, this string) bool {
-	return strings.HasPrefix(this, words[i]) && i > 0 && words[i-1] == prev
+func (c *Commander) ls(w io.Writer, args command.Args) error {
+	_, err := io.WriteString(w, "Commands: "+strings.Join(c.registry.Names(), ", "))
+	return err
 }
 
-func newCompEntries(raws ...string) []cchat.CompletionEntry {
-	var entries = make([]cchat.CompletionEntry, len(raws))
-	for i, raw := range raws {
-		entries[i] = cchat.CompletionEntry{
-			Raw:  raw,
-			Text: text.Plain(raw),
+func (c *Commander) random(w io.Writer, args command.Args) error {
+	var generator func() string
+
+	switch args.String("kind") {
+	case "paragraph":
+		generator = randomdata.Paragraph
+	case "noun":
+		generator = randomdata.Noun
+	case "silly_name":
+		generator = randomdata.SillyName
+	}
+
+	for i := 0; i < args.Int("count"); i++ {
+		// Yes, we're simulating this even in something as trivial as a
+		// command prompt. Failures are written out, and the rest continue.
+		var err = internet.SimulateAustralianCtx(args.Context())
+
+		switch err {
+		case nil:
+			_, err = fmt.Fprintln(w, generator())
+		case context.Canceled, context.DeadlineExceeded:
+			return err
+		default:
+			_, err = fmt.Fprintln(w, "Failed:", err)
+		}
+
+		if err != nil {
+			return err
 		}
 	}
 
-	return entries
+	return nil
 }
 
-func arg(sl []string, i int) string {
-	if i >= len(sl) {
-		return ""
+func (c *Commander) search(w io.Writer, args command.Args) error {
+	q, err := search.Parse(args.Words("query"))
+	if err != nil {
+		return err
+	}
+
+	if err := internet.SimulateAustralianCtx(args.Context()); err != nil {
+		return errors.Wrap(err, "Failed to search")
+	}
+
+	var found int
+
+Search:
+	for _, sv := range c.session.servers() {
+		sv, ok := sv.(*server.Server)
+		if !ok {
+			continue
+		}
+
+		for _, ch := range sv.Channels() {
+			if found >= search.MaxResults {
+				break Search
+			}
+
+			if err := args.Context().Err(); err != nil {
+				return err
+			}
+
+			if !q.MatchChannel(ch.Name().String()) {
+				continue
+			}
+
+			var where = sv.Name().String() + "/" + ch.Name().String()
+
+			for _, msg := range ch.Search(q, search.MaxResults-found) {
+				search.WriteResult(w, where, msg)
+				found++
+			}
+		}
 	}
-	return sl[i]
+
+	if found == 0 {
+		_, err = io.WriteString(w, "No results.")
+		return err
+	}
+
+	return nil
+}
+
+func (c *Commander) AsCompleter() cchat.Completer { return c }
+
+// Complete completes commands and their arguments from the registry.
+func (c *Commander) Complete(words []string, i int64) []cchat.CompletionEntry {
+	return c.registry.Complete(words, i)
 }
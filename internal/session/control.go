@@ -0,0 +1,246 @@
+package session
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/cchat-mock/internal/channel"
+	"github.com/diamondburned/cchat-mock/internal/command"
+	"github.com/diamondburned/cchat-mock/internal/internet"
+	"github.com/diamondburned/cchat-mock/internal/message"
+	"github.com/diamondburned/cchat-mock/internal/server"
+	"github.com/diamondburned/cchat-mock/internal/shared"
+	"github.com/diamondburned/cchat/text"
+	"github.com/pkg/errors"
+)
+
+// MinRate is the minimum interval between generated messages that set-rate
+// accepts.
+const MinRate = 100 * time.Millisecond
+
+// controlCommands returns the commands that steer the running simulation.
+func (c *Commander) controlCommands() []*command.Command {
+	var channelArg = command.Arg{
+		Name:     "channel",
+		Kind:     command.String,
+		Complete: c.completeChannels,
+	}
+
+	return []*command.Command{
+		{
+			Name: "inject",
+			Help: "Post a message as the given author",
+			Args: []command.Arg{
+				channelArg,
+				{Name: "author", Kind: command.String},
+				{Name: "text", Kind: command.Rest},
+			},
+			Run: c.inject,
+		},
+		{
+			Name: "spawn-server",
+			Help: "Add a new random server",
+			Run:  c.spawnServer,
+		},
+		{
+			Name: "rename-channel",
+			Help: "Rename a channel",
+			Args: []command.Arg{channelArg, {Name: "name", Kind: command.String}},
+			Run:  c.renameChannel,
+		},
+		{
+			Name: "delete",
+			Help: "Delete any message",
+			Args: []command.Arg{channelArg, {Name: "message", Kind: command.String}},
+			Run:  c.delete,
+		},
+		{
+			Name: "set-latency",
+			Help: "Set the range of the simulated network latency",
+			Args: []command.Arg{
+				{Name: "min", Kind: command.Duration},
+				{Name: "max", Kind: command.Duration},
+			},
+			Run: c.setLatency,
+		},
+		{
+			Name: "go-offline",
+			Help: "Fail all simulated IO for a while",
+			Args: []command.Arg{{Name: "duration", Kind: command.Duration}},
+			Run:  c.goOffline,
+		},
+		{
+			Name: "set-rate",
+			Help: "Set the interval between generated messages in a channel",
+			Args: []command.Arg{channelArg, {Name: "interval", Kind: command.Duration}},
+			Run:  c.setRate,
+		},
+		{
+			Name: "revoke-session",
+			Help: "Revoke this session, so all later calls fail",
+			Run:  c.revokeSession,
+		},
+		{
+			Name: "typing",
+			Help: "Make an author type in a channel",
+			Args: []command.Arg{channelArg, {Name: "author", Kind: command.String}},
+			Run:  c.typing,
+		},
+	}
+}
+
+// findChannel finds a channel by its ID or its name, where the hash is
+// optional. The first channel with the name is returned.
+func (s *Session) findChannel(name string) (*server.Server, *channel.Channel, error) {
+	for _, sv := range s.servers() {
+		sv, ok := sv.(*server.Server)
+		if !ok {
+			continue
+		}
+
+		for _, ch := range sv.Channels() {
+			if ch.ID() == name || ch.Name().String() == "#"+strings.TrimPrefix(name, "#") {
+				return sv, ch, nil
+			}
+		}
+	}
+
+	return nil, nil, errors.Errorf("Channel %q not found.", name)
+}
+
+// findAuthor finds an author by name in the server's roster. A new author is
+// made if none is found.
+func findAuthor(sv *server.Server, name string) message.Author {
+	for _, member := range sv.Roster().Members() {
+		if member.ID() == name {
+			return member.Author
+		}
+	}
+	return message.NewAuthor(text.Plain(name))
+}
+
+func (c *Commander) completeChannels(word string) []string {
+	var names []string
+
+	for _, sv := range c.session.servers() {
+		if sv, ok := sv.(*server.Server); ok {
+			for _, ch := range sv.Channels() {
+				names = append(names, ch.Name().String())
+			}
+		}
+	}
+
+	return names
+}
+
+func (c *Commander) inject(w io.Writer, args command.Args) error {
+	sv, ch, err := c.session.findChannel(args.String("channel"))
+	if err != nil {
+		return err
+	}
+
+	var author = findAuthor(sv, args.String("author"))
+	id, err := ch.Messenger().Inject(author, strings.Join(args.Words("text"), " "))
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "Injected message %s into %s.", id, ch.Name())
+	return err
+}
+
+func (c *Commander) spawnServer(w io.Writer, args command.Args) error {
+	var sv = server.New(c.session.State)
+	c.session.AddServer(sv)
+
+	_, err := fmt.Fprintf(w, "Spawned server %s (%s).", sv.Name(), sv.ID())
+	return err
+}
+
+func (c *Commander) renameChannel(w io.Writer, args command.Args) error {
+	sv, ch, err := c.session.findChannel(args.String("channel"))
+	if err != nil {
+		return err
+	}
+
+	var old = ch.Name().String()
+
+	ch.SetName(args.String("name"))
+	sv.UpdateChannel(ch)
+
+	_, err = fmt.Fprintf(w, "Renamed %s to %s.", old, ch.Name())
+	return err
+}
+
+func (c *Commander) delete(w io.Writer, args command.Args) error {
+	_, ch, err := c.session.findChannel(args.String("channel"))
+	if err != nil {
+		return err
+	}
+
+	if err := ch.Messenger().Delete(args.String("message")); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "Deleted message %s.", args.String("message"))
+	return err
+}
+
+func (c *Commander) setLatency(w io.Writer, args command.Args) error {
+	var min, max = args.Duration("min"), args.Duration("max")
+	if min < 0 || max < min {
+		return errors.New("Latency must be positive, and max must not be lower than min.")
+	}
+
+	internet.MinLatency = int(min / time.Millisecond)
+	internet.MaxLatency = int(max / time.Millisecond)
+
+	_, err := fmt.Fprintf(w, "Latency is now between %v and %v.", min, max)
+	return err
+}
+
+func (c *Commander) goOffline(w io.Writer, args command.Args) error {
+	internet.GoOffline(args.Duration("duration"))
+
+	_, err := fmt.Fprintf(w, "Offline until %s.", internet.OfflineUntil().Format(time.Kitchen))
+	return err
+}
+
+func (c *Commander) setRate(w io.Writer, args command.Args) error {
+	_, ch, err := c.session.findChannel(args.String("channel"))
+	if err != nil {
+		return err
+	}
+
+	var rate = args.Duration("interval")
+	if rate < MinRate {
+		return errors.Errorf("Interval must be at least %v.", MinRate)
+	}
+
+	ch.Messenger().SetRate(rate)
+
+	_, err = fmt.Fprintf(w, "%s now generates a message every %v.", ch.Name(), rate)
+	return err
+}
+
+func (c *Commander) typing(w io.Writer, args command.Args) error {
+	sv, ch, err := c.session.findChannel(args.String("channel"))
+	if err != nil {
+		return err
+	}
+
+	var author = findAuthor(sv, args.String("author"))
+	ch.Messenger().Broadcaster().TriggerTyping(author)
+
+	_, err = fmt.Fprintf(w, "%s is typing in %s.", author.ID(), ch.Name())
+	return err
+}
+
+func (c *Commander) revokeSession(w io.Writer, args command.Args) error {
+	shared.Revoke(c.session.State.SessionID, c.session.State.Expires)
+
+	_, err := io.WriteString(w, "Session revoked.")
+	return err
+}
@@ -0,0 +1,287 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/diamondburned/cchat-mock/internal/channel"
+	"github.com/diamondburned/cchat-mock/internal/command"
+	"github.com/diamondburned/cchat-mock/internal/internet"
+	"github.com/diamondburned/cchat-mock/internal/server"
+)
+
+// formatArg is the optional output format argument of dump commands.
+var formatArg = command.Arg{
+	Name:     "format",
+	Kind:     command.Enum,
+	Optional: true,
+	Default:  "table",
+	Choices:  []string{"table", "json"},
+}
+
+// dump is a dump of internal state as both a table and a JSON value.
+type dump struct {
+	header []string
+	rows   [][]string
+	value  interface{}
+}
+
+func (d *dump) row(cols ...interface{}) {
+	var row = make([]string, len(cols))
+	for i, col := range cols {
+		row[i] = fmt.Sprint(col)
+	}
+	d.rows = append(d.rows, row)
+}
+
+func (d dump) write(w io.Writer, format string) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(d.value)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(d.header, "\t"))
+	for _, row := range d.rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func (c *Commander) dumpCommands() []*command.Command {
+	var channelArg = command.Arg{
+		Name:     "channel",
+		Kind:     command.String,
+		Complete: c.completeChannels,
+	}
+
+	return []*command.Command{{
+		Name: "dump",
+		Help: "Print internal state as a table or JSON",
+		Subcommands: []*command.Command{
+			{
+				Name: "tree",
+				Help: "Servers and their channels with IDs",
+				Args: []command.Arg{formatArg},
+				Run:  c.dumpTree,
+			},
+			{
+				Name: "messages",
+				Help: "Message IDs and authors of a channel",
+				Args: []command.Arg{channelArg, formatArg},
+				Run:  c.dumpMessages,
+			},
+			{
+				Name: "joins",
+				Help: "Channels with active JoinServer subscriptions",
+				Args: []command.Arg{formatArg},
+				Run:  c.dumpJoins,
+			},
+			{
+				Name: "typing",
+				Help: "Channels with typing subscribers",
+				Args: []command.Arg{formatArg},
+				Run:  c.dumpTyping,
+			},
+			{
+				Name: "network",
+				Help: "The simulated network profile",
+				Args: []command.Arg{formatArg},
+				Run:  c.dumpNetwork,
+			},
+			{
+				Name: "ids",
+				Help: "The ID counters of the session and channels",
+				Args: []command.Arg{formatArg},
+				Run:  c.dumpIDs,
+			},
+		},
+	}}
+}
+
+// channelRef identifies a channel in dumps.
+type channelRef struct {
+	ServerID string `json:"server_id"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+}
+
+// eachChannel calls fn for every channel in every server.
+func (c *Commander) eachChannel(fn func(ref channelRef, ch *channel.Channel)) {
+	for _, sv := range c.session.servers() {
+		sv, ok := sv.(*server.Server)
+		if !ok {
+			continue
+		}
+
+		for _, ch := range sv.Channels() {
+			fn(channelRef{sv.ID(), ch.ID(), ch.Name().String()}, ch)
+		}
+	}
+}
+
+func (c *Commander) dumpTree(w io.Writer, args command.Args) error {
+	type leaf struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	type node struct {
+		ID       string `json:"id"`
+		Name     string `json:"name"`
+		Channels []leaf `json:"channels"`
+	}
+
+	var d = dump{header: []string{"SERVER ID", "SERVER", "CHANNEL ID", "CHANNEL"}}
+	var nodes = []node{}
+
+	for _, sv := range c.session.servers() {
+		var n = node{ID: sv.ID(), Name: sv.Name().String()}
+
+		if sv, ok := sv.(*server.Server); ok {
+			for _, ch := range sv.Channels() {
+				n.Channels = append(n.Channels, leaf{ch.ID(), ch.Name().String()})
+				d.row(n.ID, n.Name, ch.ID(), ch.Name())
+			}
+		}
+
+		nodes = append(nodes, n)
+	}
+
+	d.value = nodes
+	return d.write(w, args.String("format"))
+}
+
+func (c *Commander) dumpMessages(w io.Writer, args command.Args) error {
+	_, ch, err := c.session.findChannel(args.String("channel"))
+	if err != nil {
+		return err
+	}
+
+	type entry struct {
+		ID     string    `json:"id"`
+		Author string    `json:"author"`
+		Time   time.Time `json:"time"`
+		Edited bool      `json:"edited"`
+		Pinned bool      `json:"pinned"`
+	}
+
+	var d = dump{header: []string{"ID", "AUTHOR", "TIME", "EDITED", "PINNED"}}
+	var entries = []entry{}
+
+	for _, msg := range ch.Messenger().Messages() {
+		e := entry{
+			ID:     msg.ID(),
+			Author: msg.AuthorName(),
+			Time:   msg.Time(),
+			Edited: msg.IsEdited(),
+			Pinned: ch.Messenger().IsPinned(msg.ID()),
+		}
+		entries = append(entries, e)
+		d.row(e.ID, e.Author, e.Time.Format(time.Stamp), e.Edited, e.Pinned)
+	}
+
+	d.value = entries
+	return d.write(w, args.String("format"))
+}
+
+func (c *Commander) dumpJoins(w io.Writer, args command.Args) error {
+	type entry struct {
+		channelRef
+		Joins int `json:"joins"`
+	}
+
+	var d = dump{header: []string{"SERVER ID", "CHANNEL ID", "CHANNEL", "JOINS"}}
+	var entries = []entry{}
+
+	c.eachChannel(func(ref channelRef, ch *channel.Channel) {
+		if joins := ch.Messenger().Joins(); joins > 0 {
+			entries = append(entries, entry{ref, joins})
+			d.row(ref.ServerID, ref.ID, ref.Name, joins)
+		}
+	})
+
+	d.value = entries
+	return d.write(w, args.String("format"))
+}
+
+func (c *Commander) dumpTyping(w io.Writer, args command.Args) error {
+	type entry struct {
+		channelRef
+		Subscribers int    `json:"subscribers"`
+		Dropped     uint64 `json:"dropped"`
+	}
+
+	var d = dump{header: []string{"SERVER ID", "CHANNEL ID", "CHANNEL", "SUBSCRIBERS", "DROPPED"}}
+	var entries = []entry{}
+
+	c.eachChannel(func(ref channelRef, ch *channel.Channel) {
+		typ := ch.Messenger().Broadcaster()
+
+		if subs, dropped := typ.Subscribers(), typ.Dropped(); subs > 0 || dropped > 0 {
+			entries = append(entries, entry{ref, subs, dropped})
+			d.row(ref.ServerID, ref.ID, ref.Name, subs, dropped)
+		}
+	})
+
+	d.value = entries
+	return d.write(w, args.String("format"))
+}
+
+func (c *Commander) dumpNetwork(w io.Writer, args command.Args) error {
+	var profile = struct {
+		MinLatencyMs int        `json:"min_latency_ms"`
+		MaxLatencyMs int        `json:"max_latency_ms"`
+		CanFail      bool       `json:"can_fail"`
+		OfflineUntil *time.Time `json:"offline_until"`
+	}{
+		MinLatencyMs: internet.MinLatency,
+		MaxLatencyMs: internet.MaxLatency,
+		CanFail:      internet.CanFail,
+	}
+
+	var offline = "no"
+	if until := internet.OfflineUntil(); time.Now().Before(until) {
+		profile.OfflineUntil = &until
+		offline = "until " + until.Format(time.Stamp)
+	}
+
+	var d = dump{header: []string{"SETTING", "VALUE"}, value: profile}
+	d.row("min latency", time.Duration(profile.MinLatencyMs)*time.Millisecond)
+	d.row("max latency", time.Duration(profile.MaxLatencyMs)*time.Millisecond)
+	d.row("can fail", profile.CanFail)
+	d.row("offline", offline)
+
+	return d.write(w, args.String("format"))
+}
+
+func (c *Commander) dumpIDs(w io.Writer, args command.Args) error {
+	type entry struct {
+		channelRef
+		LastMessageID uint32 `json:"last_message_id"`
+	}
+
+	var ids = struct {
+		LastID   uint32  `json:"last_id"`
+		Channels []entry `json:"channels"`
+	}{
+		LastID:   c.session.State.LastID(),
+		Channels: []entry{},
+	}
+
+	var d = dump{header: []string{"COUNTER", "LAST ID"}, value: &ids}
+	d.row("session", ids.LastID)
+
+	c.eachChannel(func(ref channelRef, ch *channel.Channel) {
+		if id := ch.Messenger().LastID(); id > 0 {
+			ids.Channels = append(ids.Channels, entry{ref, id})
+			d.row(ref.Name+" ("+ref.ID+")", id)
+		}
+	})
+
+	return d.write(w, args.String("format"))
+}
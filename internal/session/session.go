@@ -2,20 +2,26 @@ package session
 
 import (
 	"math/rand"
+	"sync"
 
 	"github.com/diamondburned/cchat"
 	"github.com/diamondburned/cchat-mock/internal/internet"
 	"github.com/diamondburned/cchat-mock/internal/message"
 	"github.com/diamondburned/cchat-mock/internal/server"
 	"github.com/diamondburned/cchat-mock/internal/shared"
+	"github.com/diamondburned/cchat-mock/segments"
 	"github.com/diamondburned/cchat/text"
 	"github.com/diamondburned/cchat/utils/empty"
 )
 
 type Session struct {
 	empty.Session
-	State      *shared.State
+	State *shared.State
+
+	// mutex guards ServerList and containers.
+	mutex      sync.Mutex
 	ServerList []cchat.Server
+	containers []cchat.ServersContainer
 }
 
 var _ cchat.Session = (*Session)(nil)
@@ -38,31 +44,95 @@ func (s *Session) ID() string {
 }
 
 func (s *Session) Name() text.Rich {
-	return text.Plain(s.State.Username)
+	var name segments.Builder
+	name.Attributed(s.State.Username, text.AttributeBold)
+
+	return name.Rich()
 }
 
+// Disconnect stops all background work of the session, such as joined
+// channels, pending messages and typing subscriptions, and waits for it to
+// finish. Later calls on the session fail with shared.ErrDisconnected. An
+// error is returned if the background work didn't stop in time. The session is
+// left connected if the simulated network fails.
 func (s *Session) Disconnect() error {
-	s.State.SessionID = ""
+	if s.State.Context().Err() != nil {
+		return shared.ErrDisconnected
+	}
+
+	if err := internet.SimulateAustralian(); err != nil {
+		return err
+	}
+
+	var err = s.State.Close()
+	if err == shared.ErrDisconnected {
+		return err
+	}
+
+	s.mutex.Lock()
+	var servers = s.ServerList
+	s.containers = nil
+	s.mutex.Unlock()
+
+	for _, sv := range servers {
+		if sv, ok := sv.(*server.Server); ok {
+			sv.Close()
+		}
+	}
+
 	s.State.ResetID()
 
-	return internet.SimulateAustralian()
+	return err
 }
 
 func (s *Session) Servers(container cchat.ServersContainer) error {
+	if err := s.State.Check(); err != nil {
+		return err
+	}
+
 	if err := internet.SimulateAustralian(); err != nil {
 		return err
 	}
 
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !shared.HasContainer(s.containers, container) {
+		s.containers = append(s.containers, container)
+	}
 	container.SetServers(s.ServerList)
+
 	return nil
 }
 
+// servers returns a snapshot of the server list.
+func (s *Session) servers() []cchat.Server {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]cchat.Server(nil), s.ServerList...)
+}
+
+// AddServer appends the server into the list and resets the server list in
+// all containers.
+func (s *Session) AddServer(sv cchat.Server) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.ServerList = append(s.ServerList, sv)
+	var servers = append([]cchat.Server(nil), s.ServerList...)
+
+	for _, container := range s.containers {
+		container.SetServers(servers)
+	}
+}
+
 func (s *Session) AsIconer() cchat.Iconer {
 	return shared.NewStaticIcon(message.AvatarURL)
 }
 
 func (s *Session) AsCommander() cchat.Commander {
-	return &Commander{}
+	return NewCommander(s)
 }
 
 func (s *Session) AsSessionSaver() cchat.SessionSaver {
@@ -0,0 +1,118 @@
+package session
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"time"
+
+	"github.com/diamondburned/cchat-mock/internal/command"
+	"github.com/diamondburned/cchat-mock/internal/search"
+	"github.com/diamondburned/cchat-mock/internal/server"
+	"github.com/pkg/errors"
+)
+
+// tailBuffer is the number of messages that tail buffers before dropping.
+const tailBuffer = 32
+
+// streamCommands returns the commands that write their output over time until
+// they're canceled.
+func (c *Commander) streamCommands() []*command.Command {
+	return []*command.Command{
+		{
+			Name: "tail",
+			Help: "Print new messages in a joined channel as they arrive",
+			Args: []command.Arg{
+				{Name: "channel", Kind: command.String, Complete: c.completeChannels},
+				{Name: "count", Kind: command.Int, Optional: true},
+			},
+			Run: c.tail,
+		},
+		{
+			Name: "watch",
+			Help: "Print values periodically",
+			Subcommands: []*command.Command{{
+				Name: "stats",
+				Help: "Print the number of servers, channels, messages and more",
+				Args: []command.Arg{{
+					Name:     "interval",
+					Kind:     command.Duration,
+					Optional: true,
+					Default:  "1s",
+				}},
+				Run: c.watchStats,
+			}},
+		},
+	}
+}
+
+func (c *Commander) tail(w io.Writer, args command.Args) error {
+	sv, ch, err := c.session.findChannel(args.String("channel"))
+	if err != nil {
+		return err
+	}
+
+	messages, stop := ch.Messenger().Subscribe(tailBuffer)
+	defer stop()
+
+	var where = sv.Name().String() + "/" + ch.Name().String()
+	var count = args.Int("count")
+
+	for i := 0; count == 0 || i < count; i++ {
+		select {
+		case msg := <-messages:
+			search.WriteResult(w, where, msg)
+		case <-args.Context().Done():
+			return args.Context().Err()
+		}
+	}
+
+	return nil
+}
+
+func (c *Commander) watchStats(w io.Writer, args command.Args) error {
+	var interval = args.Duration("interval")
+	if interval < MinRate {
+		return errors.Errorf("Interval must be at least %v.", MinRate)
+	}
+
+	var ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.writeStats(w); err != nil {
+			return err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-args.Context().Done():
+			return args.Context().Err()
+		}
+	}
+}
+
+func (c *Commander) writeStats(w io.Writer) error {
+	var servers = c.session.servers()
+	var channels, messages, typers int
+
+	for _, sv := range servers {
+		sv, ok := sv.(*server.Server)
+		if !ok {
+			continue
+		}
+
+		for _, ch := range sv.Channels() {
+			channels++
+			messages += len(ch.Messenger().Messages())
+			typers += ch.Messenger().Broadcaster().Subscribers()
+		}
+	}
+
+	_, err := fmt.Fprintf(w,
+		"%s servers=%d channels=%d messages=%d typing-subscribers=%d goroutines=%d\n",
+		time.Now().Format("15:04:05"),
+		len(servers), channels, messages, typers, runtime.NumGoroutine(),
+	)
+	return err
+}
@@ -0,0 +1,14 @@
+package shared
+
+import "github.com/diamondburned/cchat"
+
+// HasContainer returns true if the container is already in the list, so that
+// listing into the same container twice doesn't register it twice.
+func HasContainer(containers []cchat.ServersContainer, c cchat.ServersContainer) bool {
+	for _, container := range containers {
+		if container == c {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package shared
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrDisconnected is returned by calls on a session after it has been
+// disconnected.
+var ErrDisconnected = errors.New("session disconnected")
+
+// ShutdownTimeout is how long Close waits for background goroutines to stop.
+var ShutdownTimeout = 5 * time.Second
+
+// Context returns a context that is canceled when the session is closed.
+func (s *State) Context() context.Context {
+	return s.ctx
+}
+
+// Go runs fn in a goroutine that Close waits for. The given context is
+// canceled on Close, after which fn must return promptly. ErrDisconnected is
+// returned and fn is not run if the session is already closed.
+func (s *State) Go(fn func(ctx context.Context)) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return ErrDisconnected
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		fn(s.ctx)
+	}()
+
+	return nil
+}
+
+// OnClose adds a callback to be called on Close. The callback is called
+// immediately if the session is already closed.
+func (s *State) OnClose(fn func()) {
+	s.mutex.Lock()
+
+	if s.closed {
+		s.mutex.Unlock()
+		fn()
+		return
+	}
+
+	s.onClose = append(s.onClose, fn)
+	s.mutex.Unlock()
+}
+
+// Close cancels the session context, calls all OnClose callbacks and waits up
+// to ShutdownTimeout for all goroutines started with Go to return. It returns
+// ErrDisconnected if the session is already closed, or an error if the
+// goroutines did not stop in time.
+func (s *State) Close() error {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return ErrDisconnected
+	}
+
+	s.closed = true
+	onClose := s.onClose
+	s.onClose = nil
+	s.mutex.Unlock()
+
+	s.cancel()
+
+	for _, fn := range onClose {
+		fn()
+	}
+
+	var done = make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(ShutdownTimeout):
+		return errors.Errorf("Timed out after %v waiting for session goroutines to stop.", ShutdownTimeout)
+	}
+}
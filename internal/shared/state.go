@@ -1,9 +1,11 @@
 package shared
 
 import (
+	"context"
 	"errors"
 	"math/rand"
 	"strconv"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -18,14 +20,32 @@ var ErrInvalidSession = errors.New("invalid session")
 type State struct {
 	SessionID string
 	Username  string
+	// Expires is when the session token expires.
+	Expires time.Time
 
 	lastID uint32 // used for generation
+
+	// ctx is canceled when the session is disconnected.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// mutex guards closed and onClose, and it makes sure no goroutines are
+	// added into the wait group after closing.
+	mutex   sync.Mutex
+	closed  bool
+	onClose []func()
+	wg      sync.WaitGroup
 }
 
 var _ cchat.SessionSaver = (*State)(nil)
 
+// NewState creates a new state whose token expires after SessionLifetime. A new
+// session ID is issued if sessionID is empty.
 func NewState(username, sessionID string) *State {
 	var state = &State{Username: username, SessionID: sessionID}
+	state.ctx, state.cancel = context.WithCancel(context.Background())
+	state.Expires = time.Now().Add(SessionLifetime)
+
 	if sessionID == "" {
 		state.SessionID = strconv.FormatUint(rand.Uint64(), 10)
 	}
@@ -33,6 +53,9 @@ func NewState(username, sessionID string) *State {
 	return state
 }
 
+// RestoreState restores the state from the stored session. ErrSessionExpired
+// is returned if the session has expired, and ErrUnauthorized is returned if it
+// was revoked.
 func RestoreState(store map[string]string) (*State, error) {
 	sID, ok := store["sessionID"]
 	if !ok {
@@ -44,13 +67,37 @@ func RestoreState(store map[string]string) (*State, error) {
 		return nil, ErrInvalidSession
 	}
 
-	return NewState(un, sID), nil
+	// Sessions saved before tokens expired have no expiry, so they are
+	// treated as expired rather than invalid.
+	exp, ok := store["expires"]
+	if !ok {
+		return nil, ErrSessionExpired
+	}
+
+	expires, err := time.Parse(time.RFC3339, exp)
+	if err != nil {
+		return nil, ErrInvalidSession
+	}
+
+	var state = NewState(un, sID)
+	state.Expires = expires
+
+	if err := state.checkToken(); err != nil {
+		return nil, err
+	}
+
+	return state, nil
 }
 
 func (s *State) NextID() uint32 {
 	return atomic.AddUint32(&s.lastID, 1)
 }
 
+// LastID returns the last generated ID.
+func (s *State) LastID() uint32 {
+	return atomic.LoadUint32(&s.lastID)
+}
+
 // ResetID resets the atomic ID counter.
 func (s *State) ResetID() {
 	atomic.StoreUint32(&s.lastID, 0)
@@ -60,5 +107,6 @@ func (s *State) SaveSession() map[string]string {
 	return map[string]string{
 		"sessionID": s.SessionID,
 		"username":  s.Username,
+		"expires":   s.Expires.Format(time.RFC3339),
 	}
 }
@@ -0,0 +1,89 @@
+package shared
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrSessionExpired is returned when the session token has expired.
+	ErrSessionExpired = errors.New("session expired")
+	// ErrUnauthorized is returned when the session token was revoked.
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+var (
+	// SessionLifetime is how long new session tokens are valid for.
+	SessionLifetime = 24 * time.Hour
+	// InvalidateChance is the chance that each check of a live session
+	// revokes it, to simulate being logged out remotely.
+	InvalidateChance = 0.001
+)
+
+var (
+	revokedMutex sync.Mutex
+	revoked      = map[string]time.Time{} // session ID to expiry
+)
+
+// Revoke revokes the session token that expires at the given time, making all
+// later checks fail with ErrUnauthorized. Tokens are forgotten once they
+// expire, as checks fail with ErrSessionExpired from then on.
+func Revoke(sessionID string, expires time.Time) {
+	revokedMutex.Lock()
+	defer revokedMutex.Unlock()
+
+	var now = time.Now()
+	for id, exp := range revoked {
+		if now.After(exp) {
+			delete(revoked, id)
+		}
+	}
+
+	revoked[sessionID] = expires
+}
+
+// IsRevoked returns true if the session token was revoked.
+func IsRevoked(sessionID string) bool {
+	revokedMutex.Lock()
+	defer revokedMutex.Unlock()
+
+	_, ok := revoked[sessionID]
+	return ok
+}
+
+// Check returns ErrDisconnected if the session was closed, ErrSessionExpired if
+// the session token has expired or ErrUnauthorized if it was revoked. Live
+// sessions are occasionally revoked according to InvalidateChance.
+func (s *State) Check() error {
+	if err := s.checkToken(); err != nil {
+		return err
+	}
+
+	if rand.Float64() < InvalidateChance {
+		Revoke(s.SessionID, s.Expires)
+		return ErrUnauthorized
+	}
+
+	return nil
+}
+
+// checkToken is Check without the random revocation, which is used when
+// restoring a session.
+func (s *State) checkToken() error {
+	if s.ctx.Err() != nil {
+		return ErrDisconnected
+	}
+
+	if s.SessionID == "" || IsRevoked(s.SessionID) {
+		return ErrUnauthorized
+	}
+
+	if time.Now().After(s.Expires) {
+		return ErrSessionExpired
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+// Package totp implements time-based one-time passwords as described in RFC
+// 6238, compatible with common authenticator apps.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Period is how long a code is valid for.
+	Period = 30 * time.Second
+	// Digits is the number of digits in a code.
+	Digits = 6
+	// Skew is the number of periods before and after the current one that
+	// are also accepted, to allow for clock drift.
+	Skew = 1
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewSecret generates a new random secret encoded in base32.
+func NewSecret() string {
+	var secret = make([]byte, 10)
+	if _, err := rand.Read(secret); err != nil {
+		panic("totp: failed to read random bytes: " + err.Error())
+	}
+	return encoding.EncodeToString(secret)
+}
+
+// Code computes the code for the base32 secret at the given time.
+func Code(secret string, t time.Time) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", err
+	}
+
+	return code(key, uint64(t.Unix()/int64(Period/time.Second))), nil
+}
+
+func code(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation.
+	offset := sum[len(sum)-1] & 0xF
+	value := binary.BigEndian.Uint32(sum[offset:]) & 0x7FFFFFFF
+
+	return fmt.Sprintf("%0*d", Digits, value%1000000)
+}
+
+// Validate returns true if the code is valid for the secret at the given time,
+// within the allowed skew.
+func Validate(secret, code string, t time.Time) bool {
+	for i := -Skew; i <= Skew; i++ {
+		want, err := Code(secret, t.Add(time.Duration(i)*Period))
+		if err != nil {
+			return false
+		}
+		if hmac.Equal([]byte(want), []byte(strings.TrimSpace(code))) {
+			return true
+		}
+	}
+	return false
+}
+
+// URI returns the otpauth URI of the secret, which authenticator apps can
+// import, usually from a QR code.
+func URI(issuer, account, secret string) string {
+	var v = url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+
+	return "otpauth://totp/" + url.PathEscape(issuer+":"+account) + "?" + v.Encode()
+}
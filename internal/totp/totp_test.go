@@ -0,0 +1,77 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// secret is the RFC 6238 test key "12345678901234567890" encoded in base32.
+const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// TestCode checks the SHA-1 test vectors from RFC 6238 Appendix B, truncated
+// to the last six digits.
+func TestCode(t *testing.T) {
+	var tests = []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, test := range tests {
+		code, err := Code(secret, time.Unix(test.unix, 0))
+		if err != nil {
+			t.Fatalf("Code(%d) failed: %v", test.unix, err)
+		}
+		if code != test.code {
+			t.Errorf("Code(%d) = %q, want %q", test.unix, code, test.code)
+		}
+	}
+}
+
+func TestCodeInvalidSecret(t *testing.T) {
+	if _, err := Code("not base32!", time.Now()); err == nil {
+		t.Fatal("expected an error for an invalid secret")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var now = time.Unix(1111111111, 0)
+
+	var tests = []struct {
+		name string
+		at   time.Time
+		code string
+		ok   bool
+	}{
+		{"current", now, "050471", true},
+		{"padded", now, " 050471\n", true},
+		{"previous period", now.Add(Period), "050471", true},
+		{"next period", now.Add(-Period), "050471", true},
+		{"too late", now.Add((Skew + 1) * Period), "050471", false},
+		{"wrong", now, "123456", false},
+		{"empty", now, "", false},
+	}
+
+	for _, test := range tests {
+		if ok := Validate(secret, test.code, test.at); ok != test.ok {
+			t.Errorf("%s: Validate = %v, want %v", test.name, ok, test.ok)
+		}
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	var s = NewSecret()
+
+	if _, err := Code(s, time.Now()); err != nil {
+		t.Fatalf("NewSecret returned an invalid secret %q: %v", s, err)
+	}
+	if s == NewSecret() {
+		t.Fatal("NewSecret returned the same secret twice")
+	}
+}
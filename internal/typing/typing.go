@@ -1,13 +1,21 @@
 package typing
 
 import (
+	"context"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/diamondburned/cchat"
 	"github.com/diamondburned/cchat-mock/internal/internet"
 	"github.com/diamondburned/cchat-mock/internal/message"
+	"github.com/diamondburned/cchat-mock/internal/shared"
 )
 
+// MaxTypingDuration is the duration after which a typer that started typing
+// expires if StopTyping is never called.
+const MaxTypingDuration = 30 * time.Second
+
 type Typer struct {
 	message.Author
 	time time.Time
@@ -30,59 +38,264 @@ func (t *Typer) Time() time.Time {
 	return t.time
 }
 
-type Subscriber struct {
-	self     message.Author
-	incoming chan message.Author
+type eventKind uint8
+
+const (
+	typingOnce  eventKind = iota // typing event that expires on its own
+	typingStart                  // typing until stopped
+	typingStop
+)
+
+type event struct {
+	author message.Author
+	kind   eventKind
+}
+
+// BufferSize is the number of events buffered for each subscriber.
+const BufferSize = 16
+
+// DropPolicy decides which event is dropped when a subscriber's buffer is full.
+type DropPolicy uint8
+
+const (
+	// DropOldest drops the oldest buffered event to make room for the new one.
+	DropOldest DropPolicy = iota
+	// DropNewest drops the new event.
+	DropNewest
+)
+
+// Broadcaster broadcasts typing events to all subscribed typing containers.
+// Each subscriber has its own buffer, and sending events never blocks: when a
+// buffer is full, an event is dropped according to the drop policy.
+type Broadcaster struct {
+	dropped uint64 // atomic, first for alignment
+
+	policy DropPolicy
+	state  *shared.State
+
+	mutex  sync.Mutex
+	self   message.Author
+	subs   map[*subscription]struct{}
+	closed bool
+}
+
+var _ cchat.TypingIndicator = (*Broadcaster)(nil)
+
+// NewBroadcaster creates a new broadcaster that drops the oldest events.
+// Subscriptions are stopped and waited for when the session state is closed.
+func NewBroadcaster(state *shared.State, self message.Author) *Broadcaster {
+	return NewBroadcasterWithPolicy(state, self, DropOldest)
 }
 
-func NewSubscriber(self message.Author) Subscriber {
-	return Subscriber{
-		self:     self,
-		incoming: make(chan message.Author),
+// NewBroadcasterWithPolicy creates a new broadcaster with the given drop
+// policy.
+func NewBroadcasterWithPolicy(state *shared.State, self message.Author, policy DropPolicy) *Broadcaster {
+	var b = &Broadcaster{
+		self:   self,
+		policy: policy,
+		state:  state,
+		subs:   map[*subscription]struct{}{},
 	}
+
+	state.OnClose(b.Close)
+	return b
 }
 
-func (ts Subscriber) TriggerTyping(author message.Author) {
-	ts.incoming <- author
+// SetSelf changes the author that TypingNow sends typing events as, such as
+// after the current user changes their nickname.
+func (b *Broadcaster) SetSelf(self message.Author) {
+	b.mutex.Lock()
+	b.self = self
+	b.mutex.Unlock()
 }
 
-func (ts Subscriber) TypingSubscribe(ti cchat.TypingContainer) (func(), error) {
-	var stopch = make(chan struct{})
+// Subscribers returns the number of active subscribers.
+func (b *Broadcaster) Subscribers() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
 
-	go func() {
-		var ticker = time.NewTicker(8 * time.Second)
-		defer ticker.Stop()
+	return len(b.subs)
+}
+
+// Dropped returns the total number of events dropped because of full buffers.
+func (b *Broadcaster) Dropped() uint64 {
+	return atomic.LoadUint64(&b.dropped)
+}
+
+// TriggerTyping sends a single typing event for the author, which the frontend
+// expires after TypingTimeout. It never blocks.
+func (b *Broadcaster) TriggerTyping(author message.Author) {
+	b.broadcast(event{author, typingOnce})
+}
+
+// StartTyping marks the author as typing until StopTyping is called or
+// MaxTypingDuration has passed. It never blocks.
+func (b *Broadcaster) StartTyping(author message.Author) {
+	b.broadcast(event{author, typingStart})
+}
+
+// StopTyping stops the typing that StartTyping started. It never blocks.
+func (b *Broadcaster) StopTyping(author message.Author) {
+	b.broadcast(event{author, typingStop})
+}
+
+func (b *Broadcaster) broadcast(ev event) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for sub := range b.subs {
+		if !sub.send(ev, b.policy) {
+			atomic.AddUint64(&b.dropped, 1)
+		}
+	}
+}
+
+type subscription struct {
+	events chan event
+	stop   chan struct{}
+	once   sync.Once
+}
+
+// close stops the subscription goroutine. It is safe to call multiple times.
+func (sub *subscription) close() {
+	sub.once.Do(func() { close(sub.stop) })
+}
+
+// send sends the event without blocking. False is returned if an event was
+// dropped.
+func (sub *subscription) send(ev event, policy DropPolicy) bool {
+	select {
+	case sub.events <- ev:
+		return true
+	default:
+	}
+
+	if policy == DropNewest {
+		return false
+	}
+
+	// Drop the oldest event. The receiver may have drained the buffer in the
+	// meantime, so neither of these block.
+	select {
+	case <-sub.events:
+	default:
+	}
+
+	select {
+	case sub.events <- ev:
+	default:
+	}
+
+	return false
+}
+
+// activeTyper is an author that is typing until expiry.
+type activeTyper struct {
+	author message.Author
+	expiry time.Time
+}
+
+func (b *Broadcaster) TypingSubscribe(ti cchat.TypingContainer) (func(), error) {
+	var sub = &subscription{
+		events: make(chan event, BufferSize),
+		stop:   make(chan struct{}),
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return nil, shared.ErrDisconnected
+	}
+
+	err := b.state.Go(func(ctx context.Context) {
+		// Refresh the active typers before the frontend times them out.
+		var refresh = time.NewTicker(b.TypingTimeout() / 2)
+		defer refresh.Stop()
+
+		var active = map[string]activeTyper{}
 
 		for {
 			select {
-			case <-stopch:
+			case <-sub.stop:
+				return
+			case <-ctx.Done():
 				return
-			case <-ticker.C:
-				ti.AddTyper(RandomTyper())
-			case author := <-ts.incoming:
-				ti.AddTyper(NewTyper(author))
+
+			case now := <-refresh.C:
+				for id, typer := range active {
+					if now.After(typer.expiry) {
+						delete(active, id)
+						ti.RemoveTyper(id)
+						continue
+					}
+					ti.AddTyper(NewTyper(typer.author))
+				}
+
+			case ev := <-sub.events:
+				switch ev.kind {
+				case typingOnce:
+					ti.AddTyper(NewTyper(ev.author))
+				case typingStart:
+					active[ev.author.ID()] = activeTyper{
+						author: ev.author,
+						expiry: time.Now().Add(MaxTypingDuration),
+					}
+					ti.AddTyper(NewTyper(ev.author))
+				case typingStop:
+					delete(active, ev.author.ID())
+					ti.RemoveTyper(ev.author.ID())
+				}
 			}
 		}
-	}()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	b.subs[sub] = struct{}{}
 
-	return func() { close(stopch) }, nil
+	return func() {
+		b.mutex.Lock()
+		delete(b.subs, sub)
+		b.mutex.Unlock()
+
+		sub.close()
+	}, nil
+}
+
+// Close stops all subscriptions. It is called when the session state is
+// closed, which then waits for the subscriptions to return. Later calls to
+// TypingSubscribe fail with shared.ErrDisconnected.
+func (b *Broadcaster) Close() {
+	b.mutex.Lock()
+	b.closed = true
+	for sub := range b.subs {
+		sub.close()
+		delete(b.subs, sub)
+	}
+	b.mutex.Unlock()
 }
 
 // Typing sleeps and returns possibly an error.
-func (ts Subscriber) Typing() error {
+func (b *Broadcaster) Typing() error {
 	if err := internet.SimulateAustralian(); err != nil {
 		return err
 	}
-	ts.TypingNow()
+	b.TypingNow()
 	return nil
 }
 
-// TypingNow sends a typing event immediately.
-func (ts Subscriber) TypingNow() {
-	ts.TriggerTyping(ts.self)
+// TypingNow sends a typing event immediately. It never blocks.
+func (b *Broadcaster) TypingNow() {
+	b.mutex.Lock()
+	self := b.self
+	b.mutex.Unlock()
+
+	b.TriggerTyping(self)
 }
 
 // TypingTimeout returns 5 seconds.
-func (ts Subscriber) TypingTimeout() time.Duration {
+func (b *Broadcaster) TypingTimeout() time.Duration {
 	return 5 * time.Second
 }
@@ -0,0 +1,61 @@
+package userdb
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+const (
+	// Iterations is the number of PBKDF2 iterations for new passwords.
+	Iterations = 100000
+
+	saltSize = 16
+	keySize  = 32
+)
+
+// newSalt returns a new random salt.
+func newSalt() []byte {
+	var salt = make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		panic("userdb: failed to read random bytes: " + err.Error())
+	}
+	return salt
+}
+
+// pbkdf2 derives a key from the password using PBKDF2 with HMAC-SHA256 as
+// described in RFC 8018.
+func pbkdf2(password, salt []byte, iterations, keyLen int) []byte {
+	var prf = hmac.New(sha256.New, password)
+	var hashLen = prf.Size()
+	var blocks = (keyLen + hashLen - 1) / hashLen
+
+	var key = make([]byte, 0, blocks*hashLen)
+	var u = make([]byte, hashLen)
+	var counter [4]byte
+
+	for block := 1; block <= blocks; block++ {
+		binary.BigEndian.PutUint32(counter[:], uint32(block))
+
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(counter[:])
+		key = prf.Sum(key)
+
+		t := key[len(key)-hashLen:]
+		copy(u, t)
+
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+
+			for j := range u {
+				t[j] ^= u[j]
+			}
+		}
+	}
+
+	return key[:keyLen]
+}
@@ -0,0 +1,214 @@
+// Package userdb implements a local user database file with hashed passwords
+// and lockouts after too many failed attempts.
+package userdb
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// MaxAttempts is the number of failed attempts before a user is locked.
+	MaxAttempts = 5
+	// LockDuration is how long a user stays locked.
+	LockDuration = 5 * time.Minute
+)
+
+var (
+	ErrUnknownUser   = errors.New("Unknown user.")
+	ErrWrongPassword = errors.New("Wrong password.")
+	ErrLocked        = errors.New("Too many failed attempts, try again later.")
+	ErrUserExists    = errors.New("User already exists.")
+	ErrCorruptUser   = errors.New("User record is corrupt.")
+)
+
+// User is a user in the database.
+type User struct {
+	Name       string    `json:"name"`
+	Salt       []byte    `json:"salt"`
+	Hash       []byte    `json:"hash"`
+	Iterations int       `json:"iterations"`
+	Failures   int       `json:"failures,omitempty"`
+	LockedAt   time.Time `json:"locked_at,omitempty"`
+}
+
+// Locked returns true if the user is locked at the given time.
+func (u User) Locked(now time.Time) bool {
+	return u.Failures >= MaxAttempts && now.Before(u.LockedAt.Add(LockDuration))
+}
+
+// Valid returns ErrCorruptUser if the record is missing its hash, salt or
+// iteration count, in which case no password can match it.
+func (u User) Valid() error {
+	if len(u.Hash) == 0 || len(u.Salt) == 0 || u.Iterations < 1 {
+		return ErrCorruptUser
+	}
+	return nil
+}
+
+// CheckPassword returns true if the password matches. It always returns false
+// for a corrupt record.
+func (u User) CheckPassword(password string) bool {
+	if u.Valid() != nil {
+		return false
+	}
+
+	hash := pbkdf2([]byte(password), u.Salt, u.Iterations, keySize)
+	return subtle.ConstantTimeCompare(hash, u.Hash) == 1
+}
+
+// mutex serializes access to database files.
+var mutex sync.Mutex
+
+// Authenticate checks the password of the user in the database file. Failed
+// attempts are counted, and the user is locked for LockDuration after
+// MaxAttempts failures in a row.
+func Authenticate(path, name, password string) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	users, err := load(path)
+	if err != nil {
+		return err
+	}
+
+	user, ok := users[name]
+	if !ok {
+		return ErrUnknownUser
+	}
+
+	if err := user.Valid(); err != nil {
+		return err
+	}
+
+	var now = time.Now()
+
+	if user.Locked(now) {
+		return ErrLocked
+	}
+
+	if !user.CheckPassword(password) {
+		// Start counting again after the lock expires.
+		if user.Failures >= MaxAttempts {
+			user.Failures = 0
+		}
+
+		user.Failures++
+		if user.Failures >= MaxAttempts {
+			user.LockedAt = now
+		}
+
+		users[name] = user
+
+		if err := save(path, users); err != nil {
+			return err
+		}
+		if user.Locked(now) {
+			return ErrLocked
+		}
+		return ErrWrongPassword
+	}
+
+	if user.Failures > 0 {
+		user.Failures = 0
+		user.LockedAt = time.Time{}
+		users[name] = user
+
+		return save(path, users)
+	}
+
+	return nil
+}
+
+// Register adds a new user into the database file, creating the file if
+// needed.
+func Register(path, name, password string) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	users, err := load(path)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := users[name]; ok {
+		return ErrUserExists
+	}
+
+	var salt = newSalt()
+
+	users[name] = User{
+		Name:       name,
+		Salt:       salt,
+		Hash:       pbkdf2([]byte(password), salt, Iterations, keySize),
+		Iterations: Iterations,
+	}
+
+	return save(path, users)
+}
+
+// load reads all users from the file. A missing file has no users.
+func load(path string) (map[string]User, error) {
+	var users = map[string]User{}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return users, nil
+		}
+		return nil, errors.Wrap(err, "Failed to read user database")
+	}
+
+	var list []User
+	if err := json.Unmarshal(b, &list); err != nil {
+		return nil, errors.Wrap(err, "Failed to parse user database")
+	}
+
+	for _, user := range list {
+		users[user.Name] = user
+	}
+
+	return users, nil
+}
+
+// save atomically writes all users into the file.
+func save(path string, users map[string]User) error {
+	var list = make([]User, 0, len(users))
+	for _, user := range users {
+		list = append(list, user)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	b, err := json.MarshalIndent(list, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode user database")
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".userdb-*")
+	if err != nil {
+		return errors.Wrap(err, "Failed to save user database")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "Failed to save user database")
+	}
+
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "Failed to save user database")
+	}
+
+	return errors.Wrap(os.Rename(tmp.Name(), path), "Failed to save user database")
+}
@@ -0,0 +1,141 @@
+package userdb
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestPBKDF2 checks the PBKDF2-HMAC-SHA256 test vectors from RFC 7914.
+func TestPBKDF2(t *testing.T) {
+	var tests = []struct {
+		password, salt string
+		iterations     int
+		key            string
+	}{
+		{
+			"passwd", "salt", 1,
+			"55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc" +
+				"49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783",
+		},
+		{
+			"password", "salt", 4096,
+			"c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a",
+		},
+	}
+
+	for _, test := range tests {
+		key := pbkdf2([]byte(test.password), []byte(test.salt), test.iterations, len(test.key)/2)
+		if got := hex.EncodeToString(key); got != test.key {
+			t.Errorf("pbkdf2(%q, %q, %d) = %s, want %s",
+				test.password, test.salt, test.iterations, got, test.key)
+		}
+	}
+}
+
+func TestCheckPassword(t *testing.T) {
+	var salt = []byte("salt")
+	var user = User{
+		Name:       "user",
+		Salt:       salt,
+		Hash:       pbkdf2([]byte("hunter2"), salt, 1, keySize),
+		Iterations: 1,
+	}
+
+	var tests = []struct {
+		name     string
+		user     func(User) User
+		password string
+		ok       bool
+	}{
+		{"correct", nil, "hunter2", true},
+		{"wrong", nil, "hunter3", false},
+		{"empty password", nil, "", false},
+		{"no hash", func(u User) User { u.Hash = nil; return u }, "", false},
+		{"no salt", func(u User) User { u.Salt = nil; return u }, "hunter2", false},
+		{"no iterations", func(u User) User { u.Iterations = 0; return u }, "hunter2", false},
+		{"short hash", func(u User) User { u.Hash = u.Hash[:4]; return u }, "hunter2", false},
+	}
+
+	for _, test := range tests {
+		var u = user
+		if test.user != nil {
+			u = test.user(u)
+		}
+		if ok := u.CheckPassword(test.password); ok != test.ok {
+			t.Errorf("%s: CheckPassword = %v, want %v", test.name, ok, test.ok)
+		}
+	}
+}
+
+func tempDatabase(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "userdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	return filepath.Join(dir, "users.json")
+}
+
+func TestAuthenticate(t *testing.T) {
+	var path = tempDatabase(t)
+
+	if err := Authenticate(path, "user", "hunter2"); err != ErrUnknownUser {
+		t.Fatalf("Authenticate on a missing file = %v, want ErrUnknownUser", err)
+	}
+
+	if err := Register(path, "user", "hunter2"); err != nil {
+		t.Fatal("Register failed:", err)
+	}
+	if err := Register(path, "user", "hunter2"); err != ErrUserExists {
+		t.Fatalf("Register twice = %v, want ErrUserExists", err)
+	}
+
+	if err := Authenticate(path, "user", "hunter2"); err != nil {
+		t.Fatal("Authenticate failed:", err)
+	}
+	if err := Authenticate(path, "user", "wrong"); err != ErrWrongPassword {
+		t.Fatalf("Authenticate with a wrong password = %v, want ErrWrongPassword", err)
+	}
+}
+
+func TestAuthenticateLocks(t *testing.T) {
+	var path = tempDatabase(t)
+
+	if err := Register(path, "user", "hunter2"); err != nil {
+		t.Fatal("Register failed:", err)
+	}
+
+	for i := 1; i < MaxAttempts; i++ {
+		if err := Authenticate(path, "user", "wrong"); err != ErrWrongPassword {
+			t.Fatalf("attempt %d = %v, want ErrWrongPassword", i, err)
+		}
+	}
+
+	if err := Authenticate(path, "user", "wrong"); err != ErrLocked {
+		t.Fatalf("last attempt = %v, want ErrLocked", err)
+	}
+	if err := Authenticate(path, "user", "hunter2"); err != ErrLocked {
+		t.Fatalf("correct password while locked = %v, want ErrLocked", err)
+	}
+}
+
+func TestAuthenticateCorrupt(t *testing.T) {
+	var path = tempDatabase(t)
+
+	b, err := json.Marshal([]User{{Name: "user"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Authenticate(path, "user", "anything"); err != ErrCorruptUser {
+		t.Fatalf("Authenticate on a corrupt record = %v, want ErrCorruptUser", err)
+	}
+}
@@ -0,0 +1,32 @@
+package segments
+
+import (
+	"github.com/diamondburned/cchat/text"
+	"github.com/diamondburned/cchat/utils/empty"
+)
+
+// AttributeSegment is a segment that applies text attributes such as bold or
+// italics.
+type AttributeSegment struct {
+	empty.TextSegment
+	Span
+	attr text.Attribute
+}
+
+var _ text.Segment = (*AttributeSegment)(nil)
+
+// NewAttributeSegment creates a new attribute segment over the given bounds.
+func NewAttributeSegment(start, end int, attr text.Attribute) AttributeSegment {
+	return AttributeSegment{
+		Span: Span{start, end},
+		attr: attr,
+	}
+}
+
+func (seg AttributeSegment) AsAttributor() text.Attributor {
+	return seg
+}
+
+func (seg AttributeSegment) Attribute() text.Attribute {
+	return seg.attr
+}
@@ -0,0 +1,58 @@
+package segments
+
+import (
+	"github.com/diamondburned/cchat/text"
+	"github.com/diamondburned/cchat/utils/empty"
+)
+
+// CodeblockSegment is a block segment for code with an optional language.
+type CodeblockSegment struct {
+	empty.TextSegment
+	Span
+	language string
+}
+
+var _ text.Segment = (*CodeblockSegment)(nil)
+
+// NewCodeblockSegment creates a new codeblock over the given bounds. The
+// language may be empty.
+func NewCodeblockSegment(start, end int, language string) CodeblockSegment {
+	return CodeblockSegment{
+		Span:     Span{start, end},
+		language: language,
+	}
+}
+
+func (seg CodeblockSegment) AsCodeblocker() text.Codeblocker {
+	return seg
+}
+
+func (seg CodeblockSegment) CodeblockLanguage() string {
+	return seg.language
+}
+
+// QuoteSegment is a block segment for quotes.
+type QuoteSegment struct {
+	empty.TextSegment
+	Span
+	prefix string
+}
+
+var _ text.Segment = (*QuoteSegment)(nil)
+
+// NewQuoteSegment creates a new quoteblock over the given bounds. The prefix is
+// typically ">".
+func NewQuoteSegment(start, end int, prefix string) QuoteSegment {
+	return QuoteSegment{
+		Span:   Span{start, end},
+		prefix: prefix,
+	}
+}
+
+func (seg QuoteSegment) AsQuoteblocker() text.Quoteblocker {
+	return seg
+}
+
+func (seg QuoteSegment) QuotePrefix() string {
+	return seg.prefix
+}
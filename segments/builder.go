@@ -0,0 +1,147 @@
+package segments
+
+import (
+	"strings"
+
+	"github.com/diamondburned/cchat/text"
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// Builder builds a text.Rich by appending spans of styled text. The bounds of
+// each segment are calculated from the byte offsets of the written spans. The
+// zero value is ready to use.
+//
+// Block segments such as codeblocks and quotes are not separated by newlines
+// automatically; callers should write them in between if needed.
+type Builder struct {
+	content  strings.Builder
+	segments []text.Segment
+}
+
+// Len returns the length of the content written so far in bytes.
+func (b *Builder) Len() int {
+	return b.content.Len()
+}
+
+// Write writes plain text and returns its span.
+func (b *Builder) Write(s string) Span {
+	var start = b.content.Len()
+	b.content.WriteString(s)
+	return Span{start, b.content.Len()}
+}
+
+// WriteByte writes a single plain byte.
+func (b *Builder) WriteByte(c byte) error {
+	return b.content.WriteByte(c)
+}
+
+// Add adds the given segment, whose bounds must be absolute offsets into the
+// content. Empty segments are kept, since images may be zero-length.
+func (b *Builder) Add(seg text.Segment) {
+	b.segments = append(b.segments, seg)
+}
+
+// Append writes the given rich text, offsetting its segments to where it's
+// written.
+func (b *Builder) Append(r text.Rich) Span {
+	var span = b.Write(r.Content)
+	for _, seg := range r.Segments {
+		b.Add(offsetSegment{seg, span.Start})
+	}
+	return span
+}
+
+// Colored writes text colored with the given 24-bit RGB color.
+func (b *Builder) Colored(s string, color uint32) Span {
+	var span = b.Write(s)
+	b.Add(NewColoredSpan(span.Start, span.End, NewColored(color)))
+	return span
+}
+
+// Colorful writes text colored with the given color.
+func (b *Builder) Colorful(s string, color colorful.Color) Span {
+	var span = b.Write(s)
+	b.Add(NewColoredSpan(span.Start, span.End, NewColorful(color)))
+	return span
+}
+
+// Attributed writes text with the given attributes, such as bold or italics.
+func (b *Builder) Attributed(s string, attr text.Attribute) Span {
+	var span = b.Write(s)
+	b.Add(NewAttributeSegment(span.Start, span.End, attr))
+	return span
+}
+
+// Code writes inline code.
+func (b *Builder) Code(s string) Span {
+	return b.Attributed(s, text.AttributeMonospace)
+}
+
+// Linked writes text that links to the given URL.
+func (b *Builder) Linked(s, url string) Span {
+	var span = b.Write(s)
+	b.Add(NewLinkSegment(span.Start, span.End, url))
+	return span
+}
+
+// Mention writes a mention with the given popup information. If color is 0,
+// then the mention is not colored.
+func (b *Builder) Mention(s string, info text.Rich, color uint32) Span {
+	var colored Colored
+	if color != 0 {
+		colored = NewColored(color)
+	}
+
+	var span = b.Write(s)
+	b.Add(NewMentionSegment(span.Start, span.End, info, colored))
+	return span
+}
+
+// Codeblock writes a code block with an optional language.
+func (b *Builder) Codeblock(s, language string) Span {
+	var span = b.Write(s)
+	b.Add(NewCodeblockSegment(span.Start, span.End, language))
+	return span
+}
+
+// Quote writes a quote block.
+func (b *Builder) Quote(s string) Span {
+	var span = b.Write(s)
+	b.Add(NewQuoteSegment(span.Start, span.End, ">"))
+	return span
+}
+
+// Image inserts a zero-length inline image at the current position. The width
+// and height may be 0 if unknown.
+func (b *Builder) Image(url, alt string, w, h int) Span {
+	var span = Span{b.Len(), b.Len()}
+	b.Add(NewImageSegment(span.Start, span.End, url, alt, w, h))
+	return span
+}
+
+// Avatar inserts a zero-length inline avatar at the current position. The size
+// may be 0 if unknown.
+func (b *Builder) Avatar(url, alt string, size int) Span {
+	var span = Span{b.Len(), b.Len()}
+	b.Add(NewAvatarSegment(span.Start, span.End, url, alt, size))
+	return span
+}
+
+// Rich returns the built rich text.
+func (b *Builder) Rich() text.Rich {
+	return text.Rich{
+		Content:  b.content.String(),
+		Segments: b.segments,
+	}
+}
+
+// offsetSegment shifts the bounds of a segment by an offset.
+type offsetSegment struct {
+	text.Segment
+	offset int
+}
+
+func (seg offsetSegment) Bounds() (start, end int) {
+	start, end = seg.Segment.Bounds()
+	return start + seg.offset, end + seg.offset
+}
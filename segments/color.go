@@ -16,35 +16,41 @@ func init() {
 
 type ColoredSegment struct {
 	empty.TextSegment
-	strlen  int
+	Span
 	colored Colored
 }
 
 var _ text.Segment = (*ColoredSegment)(nil)
 
+// NewColoredSegment creates a new colored segment that covers the whole given
+// string.
 func NewColoredSegment(str string, color uint32) ColoredSegment {
 	return ColoredSegment{
-		strlen:  len(str),
+		Span:    Span{0, len(str)},
 		colored: NewColored(color),
 	}
 }
 
 func NewRandomColoredSegment(str string) ColoredSegment {
 	return ColoredSegment{
-		strlen:  len(str),
+		Span:    Span{0, len(str)},
 		colored: NewRandomColored(),
 	}
 }
 
 func NewColorfulSegment(str string, color colorful.Color) ColoredSegment {
 	return ColoredSegment{
-		strlen:  len(str),
+		Span:    Span{0, len(str)},
 		colored: NewColorful(color),
 	}
 }
 
-func (seg ColoredSegment) Bounds() (start, end int) {
-	return 0, seg.strlen
+// NewColoredSpan creates a new colored segment over the given bounds.
+func NewColoredSpan(start, end int, color Colored) ColoredSegment {
+	return ColoredSegment{
+		Span:    Span{start, end},
+		colored: color,
+	}
 }
 
 func (seg ColoredSegment) AsColorer() text.Colorer {
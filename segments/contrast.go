@@ -0,0 +1,98 @@
+package segments
+
+import (
+	"strings"
+
+	"github.com/lucasb-eyer/go-colorful"
+	"github.com/pkg/errors"
+)
+
+// MinContrast is the minimum contrast ratio for normal text, as recommended by
+// WCAG 2.0 level AA.
+const MinContrast = 4.5
+
+var (
+	// DarkBackground is a typical dark theme background color.
+	DarkBackground = colorful.Color{R: 0.12, G: 0.12, B: 0.12}
+	// LightBackground is a typical light theme background color.
+	LightBackground = colorful.Color{R: 1, G: 1, B: 1}
+)
+
+// ParseBackground parses "dark", "light" or a hex color such as "#282a36" into
+// a background color.
+func ParseBackground(bg string) (colorful.Color, error) {
+	switch strings.ToLower(bg) {
+	case "dark":
+		return DarkBackground, nil
+	case "light":
+		return LightBackground, nil
+	}
+
+	c, err := colorful.Hex(bg)
+	if err != nil {
+		return colorful.Color{}, errors.Wrap(err, "expected dark, light or a hex color")
+	}
+
+	return c, nil
+}
+
+// Luminance returns the relative luminance of the color as defined by WCAG.
+func Luminance(c colorful.Color) float64 {
+	r, g, b := c.LinearRgb()
+	return 0.2126*r + 0.7152*g + 0.0722*b
+}
+
+// ContrastRatio returns the WCAG contrast ratio between two colors, which
+// ranges from 1 to 21.
+func ContrastRatio(a, b colorful.Color) float64 {
+	la, lb := Luminance(a), Luminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// EnsureContrast adjusts the lightness of the color until it has at least the
+// given contrast ratio against the background. The hue and chroma are kept as
+// much as possible. Colors that already have enough contrast are returned as-is.
+func EnsureContrast(c, bg colorful.Color, ratio float64) colorful.Color {
+	c = c.Clamped()
+	if ContrastRatio(c, bg) >= ratio {
+		return c
+	}
+
+	h, chroma, l := c.Hcl()
+	light := Luminance(bg) > 0.18
+
+	ok := func(l float64) bool {
+		return ContrastRatio(colorful.Hcl(h, chroma, l).Clamped(), bg) >= ratio
+	}
+
+	// Binary search for the lightness closest to the original that still has
+	// enough contrast: darker on light backgrounds and lighter on dark ones.
+	// The bad bound is the original lightness, and the good bound is the
+	// extreme.
+	var bad, good = l, 1.0
+	if light {
+		good = 0
+	}
+
+	for i := 0; i < 16; i++ {
+		mid := (bad + good) / 2
+		if ok(mid) {
+			good = mid
+		} else {
+			bad = mid
+		}
+	}
+
+	if adjusted := colorful.Hcl(h, chroma, good).Clamped(); ok(good) {
+		return adjusted
+	}
+
+	// The chroma makes it impossible, so fall back to black or white.
+	if light {
+		return colorful.Color{R: 0, G: 0, B: 0}
+	}
+	return colorful.Color{R: 1, G: 1, B: 1}
+}
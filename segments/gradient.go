@@ -0,0 +1,78 @@
+package segments
+
+import (
+	"math"
+	"math/rand"
+	"unicode/utf8"
+
+	"github.com/diamondburned/cchat/text"
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// NewGradientSegments returns one colored segment per rune of str, with the
+// colors interpolated across the given color stops in the HCL color space. The
+// bounds of the segments are offset by the given offset. If no colors are
+// given, then nil is returned.
+func NewGradientSegments(str string, offset int, colors ...colorful.Color) []text.Segment {
+	if len(colors) == 0 {
+		return nil
+	}
+
+	var runes = utf8.RuneCountInString(str)
+	var segs = make([]text.Segment, 0, runes)
+
+	for i, n := 0, 0; i < len(str); n++ {
+		_, size := utf8.DecodeRuneInString(str[i:])
+
+		var t float64
+		if runes > 1 {
+			t = float64(n) / float64(runes-1)
+		}
+
+		segs = append(segs, NewColoredSpan(
+			offset+i, offset+i+size, NewColorful(interpolate(colors, t)),
+		))
+
+		i += size
+	}
+
+	return segs
+}
+
+// interpolate returns the color at t, from 0 to 1, along the color stops.
+func interpolate(colors []colorful.Color, t float64) colorful.Color {
+	if len(colors) == 1 {
+		return colors[0]
+	}
+
+	var pos = t * float64(len(colors)-1)
+	var i = int(math.Floor(pos))
+	if i >= len(colors)-1 {
+		return colors[len(colors)-1]
+	}
+
+	return colors[i].BlendHcl(colors[i+1], pos-float64(i)).Clamped()
+}
+
+// RandomGradient returns 2 or 3 random color stops of different hues that
+// NewGradientSegments can use.
+func RandomGradient() []colorful.Color {
+	var hue = rand.Float64() * 360
+	var stops = make([]colorful.Color, rand.Intn(2)+2)
+
+	for i := range stops {
+		stops[i] = colorful.Hcl(math.Mod(hue+float64(i)*120, 360), 0.6, 0.75)
+	}
+
+	return stops
+}
+
+// Gradient writes text with a per-rune color gradient across the given color
+// stops.
+func (b *Builder) Gradient(s string, colors ...colorful.Color) Span {
+	var span = b.Write(s)
+	for _, seg := range NewGradientSegments(s, span.Start, colors...) {
+		b.Add(seg)
+	}
+	return span
+}
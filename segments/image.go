@@ -0,0 +1,83 @@
+package segments
+
+import (
+	"github.com/diamondburned/cchat/text"
+	"github.com/diamondburned/cchat/utils/empty"
+)
+
+// ImageSegment is an inline image. Its bounds are usually zero-length.
+type ImageSegment struct {
+	empty.TextSegment
+	Span
+	url  string
+	text string
+	w, h int
+}
+
+var _ text.Segment = (*ImageSegment)(nil)
+
+// NewImageSegment creates a new image over the given bounds. The width and
+// height may be 0 if unknown.
+func NewImageSegment(start, end int, url, text string, w, h int) ImageSegment {
+	return ImageSegment{
+		Span: Span{start, end},
+		url:  url,
+		text: text,
+		w:    w,
+		h:    h,
+	}
+}
+
+func (seg ImageSegment) AsImager() text.Imager {
+	return seg
+}
+
+func (seg ImageSegment) Image() string {
+	return seg.url
+}
+
+func (seg ImageSegment) ImageText() string {
+	return seg.text
+}
+
+func (seg ImageSegment) ImageSize() (w, h int) {
+	return seg.w, seg.h
+}
+
+// AvatarSegment is an inline rounded image. Its bounds are usually zero-length.
+type AvatarSegment struct {
+	empty.TextSegment
+	Span
+	url  string
+	text string
+	size int
+}
+
+var _ text.Segment = (*AvatarSegment)(nil)
+
+// NewAvatarSegment creates a new avatar over the given bounds. The size may be
+// 0 if unknown.
+func NewAvatarSegment(start, end int, url, text string, size int) AvatarSegment {
+	return AvatarSegment{
+		Span: Span{start, end},
+		url:  url,
+		text: text,
+		size: size,
+	}
+}
+
+func (seg AvatarSegment) AsAvatarer() text.Avatarer {
+	return seg
+}
+
+func (seg AvatarSegment) Avatar() string {
+	return seg.url
+}
+
+func (seg AvatarSegment) AvatarText() string {
+	return seg.text
+}
+
+func (seg AvatarSegment) AvatarSize() int {
+	return seg.size
+}
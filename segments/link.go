@@ -0,0 +1,31 @@
+package segments
+
+import (
+	"github.com/diamondburned/cchat/text"
+	"github.com/diamondburned/cchat/utils/empty"
+)
+
+// LinkSegment is a segment that turns the text into a hyperlink.
+type LinkSegment struct {
+	empty.TextSegment
+	Span
+	url string
+}
+
+var _ text.Segment = (*LinkSegment)(nil)
+
+// NewLinkSegment creates a new hyperlink over the given bounds.
+func NewLinkSegment(start, end int, url string) LinkSegment {
+	return LinkSegment{
+		Span: Span{start, end},
+		url:  url,
+	}
+}
+
+func (seg LinkSegment) AsLinker() text.Linker {
+	return seg
+}
+
+func (seg LinkSegment) Link() string {
+	return seg.url
+}
@@ -0,0 +1,42 @@
+package segments
+
+import (
+	"github.com/diamondburned/cchat/text"
+	"github.com/diamondburned/cchat/utils/empty"
+)
+
+// MentionSegment is a clickable segment that shows information about the
+// mentioned user. It is optionally colored.
+type MentionSegment struct {
+	empty.TextSegment
+	Span
+	info  text.Rich
+	color Colored
+}
+
+var _ text.Segment = (*MentionSegment)(nil)
+
+// NewMentionSegment creates a new mention over the given bounds. If color is
+// 0, then the mention is not colored.
+func NewMentionSegment(start, end int, info text.Rich, color Colored) MentionSegment {
+	return MentionSegment{
+		Span:  Span{start, end},
+		info:  info,
+		color: color,
+	}
+}
+
+func (seg MentionSegment) AsMentioner() text.Mentioner {
+	return seg
+}
+
+func (seg MentionSegment) AsColorer() text.Colorer {
+	if seg.color == 0 {
+		return nil
+	}
+	return seg.color
+}
+
+func (seg MentionSegment) MentionInfo() text.Rich {
+	return seg.info
+}
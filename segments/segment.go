@@ -0,0 +1,23 @@
+package segments
+
+// Span is a byte range inside a text.Rich's content. Segments embed it to
+// implement the Bounds method.
+type Span struct {
+	Start int
+	End   int
+}
+
+// NewSpan creates a new span.
+func NewSpan(start, end int) Span {
+	return Span{start, end}
+}
+
+// Bounds returns the start and end of the span.
+func (s Span) Bounds() (start, end int) {
+	return s.Start, s.End
+}
+
+// Len returns the length of the span in bytes.
+func (s Span) Len() int {
+	return s.End - s.Start
+}
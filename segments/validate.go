@@ -0,0 +1,261 @@
+package segments
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/diamondburned/cchat/text"
+)
+
+// Severity is the severity of a diagnostic.
+type Severity uint8
+
+const (
+	// Warning is for segments that are valid but may render badly on some
+	// frontends.
+	Warning Severity = iota
+	// Error is for segments that are invalid.
+	Error
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	default:
+		return fmt.Sprintf("Severity(%d)", s)
+	}
+}
+
+// Problem is the kind of problem that a diagnostic describes.
+type Problem uint8
+
+const (
+	// OutOfRange is when a bound is negative or past the end of the content.
+	OutOfRange Problem = iota + 1
+	// InvertedRange is when the start bound is after the end bound.
+	InvertedRange
+	// SplitRune is when a bound is in the middle of a UTF-8 sequence.
+	SplitRune
+	// Overlap is when two segments cross each other instead of nesting. It is
+	// an error if either segment is a block segment.
+	Overlap
+	// Empty is when a segment other than an image or avatar has zero length.
+	Empty
+)
+
+func (p Problem) String() string {
+	switch p {
+	case OutOfRange:
+		return "out of range"
+	case InvertedRange:
+		return "inverted range"
+	case SplitRune:
+		return "splits UTF-8 sequence"
+	case Overlap:
+		return "illegal overlap"
+	case Empty:
+		return "empty segment"
+	default:
+		return fmt.Sprintf("Problem(%d)", p)
+	}
+}
+
+// Diagnostic describes a problem with a segment.
+type Diagnostic struct {
+	Problem  Problem
+	Severity Severity
+	// Segment is the index of the segment with the problem.
+	Segment int
+	// Other is the index of the other segment for overlaps, or -1.
+	Other int
+	// Start and End are the bounds of the segment.
+	Start int
+	End   int
+}
+
+// Error formats the diagnostic. Diagnostic satisfies the error interface.
+func (d Diagnostic) Error() string {
+	var msg = fmt.Sprintf(
+		"%s: segment %d [%d, %d): %s", d.Severity, d.Segment, d.Start, d.End, d.Problem,
+	)
+	if d.Other > -1 {
+		msg += fmt.Sprintf(" with segment %d", d.Other)
+	}
+	return msg
+}
+
+// ValidationError is the error returned by Check. It contains all diagnostics.
+type ValidationError struct {
+	Diagnostics []Diagnostic
+}
+
+func (err *ValidationError) Error() string {
+	var lines = make([]string, len(err.Diagnostics))
+	for i, d := range err.Diagnostics {
+		lines[i] = d.Error()
+	}
+	return strings.Join(lines, "; ")
+}
+
+// Check validates the rich text and returns a *ValidationError if there are any
+// diagnostics with the Error severity.
+func Check(r text.Rich) error {
+	var diags = Validate(r)
+
+	for _, d := range diags {
+		if d.Severity == Error {
+			return &ValidationError{diags}
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the segments of the rich text for out-of-range bounds, bounds
+// that split UTF-8 sequences, inverted ranges and illegal overlaps. It returns
+// nil if there are no problems.
+func Validate(r text.Rich) []Diagnostic {
+	var diags []Diagnostic
+
+	report := func(p Problem, sev Severity, i, other, start, end int) {
+		diags = append(diags, Diagnostic{
+			Problem:  p,
+			Severity: sev,
+			Segment:  i,
+			Other:    other,
+			Start:    start,
+			End:      end,
+		})
+	}
+
+	for i, seg := range r.Segments {
+		start, end := seg.Bounds()
+
+		if start < 0 || end < 0 || start > len(r.Content) || end > len(r.Content) {
+			report(OutOfRange, Error, i, -1, start, end)
+			continue
+		}
+
+		if start > end {
+			report(InvertedRange, Error, i, -1, start, end)
+			continue
+		}
+
+		if !isBoundary(r.Content, start) || !isBoundary(r.Content, end) {
+			report(SplitRune, Error, i, -1, start, end)
+		}
+
+		if start == end && seg.AsImager() == nil && seg.AsAvatarer() == nil {
+			report(Empty, Warning, i, -1, start, end)
+		}
+	}
+
+	// Check for crossing segments. Only check segments with valid bounds.
+	for _, pair := range crossings(r) {
+		i, j := pair[0], pair[1]
+		seg, other := r.Segments[i], r.Segments[j]
+
+		var sev = Warning
+		if isBlock(seg) || isBlock(other) {
+			sev = Error
+		}
+
+		start, end := seg.Bounds()
+		report(Overlap, sev, i, j, start, end)
+	}
+
+	return diags
+}
+
+// isBoundary returns true if i is a valid rune boundary in s.
+func isBoundary(s string, i int) bool {
+	return i == len(s) || utf8.RuneStart(s[i])
+}
+
+func validBounds(s string, start, end int) bool {
+	return start >= 0 && start <= end && end <= len(s)
+}
+
+// span is a segment with valid bounds, remembering its index for crossings.
+type span struct {
+	index      int
+	start, end int
+}
+
+// crossings returns the index pairs of all segments that cross each other,
+// with the lower index first, sorted by indices. Segments are swept in order of
+// their start while keeping the segments that are still open sorted by their
+// end, so that only the open segments ending inside the current one are
+// visited.
+func crossings(r text.Rich) [][2]int {
+	var spans = make([]span, 0, len(r.Segments))
+	for i, seg := range r.Segments {
+		start, end := seg.Bounds()
+		if validBounds(r.Content, start, end) {
+			spans = append(spans, span{i, start, end})
+		}
+	}
+
+	// Sort outer segments before the inner segments that they contain.
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		return spans[i].end > spans[j].end
+	})
+
+	var pairs [][2]int
+	var open []span // sorted by end
+
+	for _, sp := range spans {
+		// Forget the segments that end before this one starts.
+		n := sort.Search(len(open), func(i int) bool { return open[i].end > sp.start })
+		open = open[n:]
+
+		// All open segments start at or before this one, so only those that
+		// end inside it can cross it.
+		n = sort.Search(len(open), func(i int) bool { return open[i].end >= sp.end })
+		for _, o := range open[:n] {
+			if crosses(o.start, o.end, sp.start, sp.end) {
+				pairs = append(pairs, orderedPair(o.index, sp.index))
+			}
+		}
+
+		n = sort.Search(len(open), func(i int) bool { return open[i].end > sp.end })
+		open = append(open, span{})
+		copy(open[n+1:], open[n:])
+		open[n] = sp
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+
+	return pairs
+}
+
+func orderedPair(i, j int) [2]int {
+	if i > j {
+		i, j = j, i
+	}
+	return [2]int{i, j}
+}
+
+// crosses returns true if the two ranges partially overlap, that is, they
+// overlap but neither contains the other.
+func crosses(start, end, ostart, oend int) bool {
+	return (start < ostart && ostart < end && end < oend) ||
+		(ostart < start && start < oend && oend < end)
+}
+
+func isBlock(seg text.Segment) bool {
+	return seg.AsCodeblocker() != nil || seg.AsQuoteblocker() != nil
+}
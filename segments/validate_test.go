@@ -0,0 +1,146 @@
+package segments
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/diamondburned/cchat/text"
+)
+
+func TestValidate(t *testing.T) {
+	var tests = []struct {
+		name     string
+		content  string
+		segments []text.Segment
+		want     []Diagnostic
+	}{
+		{
+			name:     "valid",
+			content:  "hello world",
+			segments: []text.Segment{NewLinkSegment(0, 5, ""), NewLinkSegment(6, 11, "")},
+		},
+		{
+			name:    "nested",
+			content: "hello world",
+			segments: []text.Segment{
+				NewLinkSegment(0, 11, ""),
+				NewLinkSegment(0, 5, ""),
+				NewLinkSegment(6, 11, ""),
+			},
+		},
+		{
+			name:     "out of range",
+			content:  "hello",
+			segments: []text.Segment{NewLinkSegment(2, 6, "")},
+			want:     []Diagnostic{{OutOfRange, Error, 0, -1, 2, 6}},
+		},
+		{
+			name:     "negative",
+			content:  "hello",
+			segments: []text.Segment{NewLinkSegment(-1, 2, "")},
+			want:     []Diagnostic{{OutOfRange, Error, 0, -1, -1, 2}},
+		},
+		{
+			name:     "inverted",
+			content:  "hello",
+			segments: []text.Segment{NewLinkSegment(3, 1, "")},
+			want:     []Diagnostic{{InvertedRange, Error, 0, -1, 3, 1}},
+		},
+		{
+			name:     "split rune",
+			content:  "héllo",
+			segments: []text.Segment{NewLinkSegment(0, 2, "")},
+			want:     []Diagnostic{{SplitRune, Error, 0, -1, 0, 2}},
+		},
+		{
+			name:     "empty",
+			content:  "hello",
+			segments: []text.Segment{NewLinkSegment(2, 2, "")},
+			want:     []Diagnostic{{Empty, Warning, 0, -1, 2, 2}},
+		},
+		{
+			name:    "inline overlap",
+			content: "hello world",
+			segments: []text.Segment{
+				NewLinkSegment(0, 7, ""),
+				NewLinkSegment(3, 11, ""),
+			},
+			want: []Diagnostic{{Overlap, Warning, 0, 1, 0, 7}},
+		},
+		{
+			name:    "block overlap",
+			content: "hello world",
+			segments: []text.Segment{
+				NewLinkSegment(3, 11, ""),
+				NewCodeblockSegment(0, 7, ""),
+			},
+			want: []Diagnostic{{Overlap, Error, 0, 1, 3, 11}},
+		},
+		{
+			name:    "touching",
+			content: "hello world",
+			segments: []text.Segment{
+				NewCodeblockSegment(0, 5, ""),
+				NewLinkSegment(5, 11, ""),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		var got = Validate(text.Rich{Content: test.content, Segments: test.segments})
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: Validate = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	var warning = text.Rich{
+		Content:  "hello",
+		Segments: []text.Segment{NewLinkSegment(0, 3, ""), NewLinkSegment(1, 5, "")},
+	}
+	if err := Check(warning); err != nil {
+		t.Errorf("Check with only warnings = %v, want nil", err)
+	}
+
+	var invalid = text.Rich{
+		Content:  "hello",
+		Segments: []text.Segment{NewLinkSegment(0, 6, "")},
+	}
+	if _, ok := Check(invalid).(*ValidationError); !ok {
+		t.Errorf("Check with errors = %v, want a *ValidationError", Check(invalid))
+	}
+}
+
+// TestCrossings compares crossings against checking every pair of segments.
+func TestCrossings(t *testing.T) {
+	var r = rand.New(rand.NewSource(1))
+
+	for round := 0; round < 200; round++ {
+		var rich = text.Rich{Content: strings.Repeat("a", 20)}
+		for i := r.Intn(30); i > 0; i-- {
+			start, end := r.Intn(22)-1, r.Intn(22)-1
+			rich.Segments = append(rich.Segments, NewLinkSegment(start, end, ""))
+		}
+
+		var want [][2]int
+		for i, seg := range rich.Segments {
+			start, end := seg.Bounds()
+			if !validBounds(rich.Content, start, end) {
+				continue
+			}
+			for j := i + 1; j < len(rich.Segments); j++ {
+				ostart, oend := rich.Segments[j].Bounds()
+				if validBounds(rich.Content, ostart, oend) && crosses(start, end, ostart, oend) {
+					want = append(want, [2]int{i, j})
+				}
+			}
+		}
+
+		if got := crossings(rich); !reflect.DeepEqual(got, want) {
+			t.Fatalf("round %d: crossings = %v, want %v", round, got, want)
+		}
+	}
+}
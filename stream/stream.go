@@ -0,0 +1,26 @@
+// Package stream defines an optional extension of cchat.Commander for commands
+// whose output is written over time, such as tailing a channel.
+package stream
+
+import (
+	"context"
+	"io"
+
+	"github.com/diamondburned/cchat"
+)
+
+// Streamer is implemented by commanders that can write the output of a
+// command as it is produced. Frontends can assert a cchat.Commander for it to
+// show progressive output and to cancel long-running commands.
+type Streamer interface {
+	// RunContext runs the command and writes its output into w as it goes
+	// until the command finishes or the context is canceled.
+	RunContext(ctx context.Context, words []string, w io.Writer) error
+}
+
+// AsStreamer returns the commander as a Streamer, or nil if it doesn't support
+// streaming.
+func AsStreamer(cmd cchat.Commander) Streamer {
+	s, _ := cmd.(Streamer)
+	return s
+}